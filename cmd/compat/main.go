@@ -0,0 +1,113 @@
+// Command compat writes the compatibility matrix of the protocols, listing the packets of the latest version
+// that each version has no packet for. It is run through go generate in the compat package.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/oomph-ac/new-mv/compat"
+	"github.com/oomph-ac/new-mv/internal/hud"
+	"github.com/oomph-ac/new-mv/protocols/v630"
+	"github.com/oomph-ac/new-mv/protocols/v649"
+	"github.com/oomph-ac/new-mv/protocols/v662"
+	"github.com/oomph-ac/new-mv/protocols/v671"
+	"github.com/oomph-ac/new-mv/protocols/v685"
+	"github.com/oomph-ac/new-mv/protocols/v686"
+	"github.com/oomph-ac/new-mv/protocols/v712"
+	"github.com/oomph-ac/new-mv/protocols/v729"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// protocol is a protocol version of which the compatibility is listed.
+type protocol interface {
+	ID() int32
+	Ver() string
+	Unsupported() []uint32
+}
+
+// protocols holds the protocols listed, from the oldest to the newest version.
+var protocols = []protocol{
+	v630.Protocol{},
+	v649.Protocol{},
+	v662.Protocol{},
+	v671.Protocol{},
+	v685.Protocol{},
+	v686.Protocol{},
+	v712.Protocol{},
+	v729.Protocol{},
+}
+
+func main() {
+	out := flag.String("o", "COMPATIBILITY.md", "file to write the compatibility matrix to")
+	flag.Parse()
+
+	if err := os.WriteFile(*out, []byte(matrix()), 0644); err != nil {
+		log.Fatalf("write compatibility matrix: %v", err)
+	}
+}
+
+// matrix returns the compatibility matrix of the protocols as a Markdown document.
+func matrix() string {
+	pool := packet.NewServerPool()
+	unsupported := make([]map[string]struct{}, len(protocols))
+	var names []string
+	for i, p := range protocols {
+		unsupported[i] = make(map[string]struct{})
+		for _, id := range p.Unsupported() {
+			name := compat.Name(pool[id]())
+			unsupported[i][name] = struct{}{}
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	b.WriteString("# Compatibility\n\n")
+	b.WriteString("<!-- Code generated by go generate ./compat. DO NOT EDIT. -->\n\n")
+	b.WriteString("Packets sent by servers of the latest version that a version has no packet for. These packets are\n")
+	b.WriteString("handled by the unsupported packet policy of the protocol (see `compat.Policy`), and are dropped by\n")
+	b.WriteString("default. Packets supported by every version are left out.\n\n")
+	b.WriteString("MovementEffect is converted to its closest equivalent instead, and SetHud is emulated as described\n")
+	b.WriteString("below. SetMovementAuthority is dropped, as older clients cannot change their movement authority after\n")
+	b.WriteString("joining.\n\n")
+
+	b.WriteString("| Packet |")
+	for _, p := range protocols {
+		fmt.Fprintf(&b, " %v (%v) |", p.Ver(), p.ID())
+	}
+	b.WriteString("\n| --- |")
+	for range protocols {
+		b.WriteString(" :---: |")
+	}
+	b.WriteString("\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "| %v |", name)
+		for i := range protocols {
+			if _, ok := unsupported[i][name]; ok {
+				b.WriteString(" ✗ |")
+			} else {
+				b.WriteString(" ✓ |")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n## SetHud\n\n")
+	b.WriteString("Versions without SetHud emulate it with packets they do understand instead of applying the policy.\n")
+	b.WriteString("Only some HUD elements can be emulated, hiding or showing the others has no effect.\n\n")
+	b.WriteString("| HUD element | Emulated |\n| --- | :---: |\n")
+	for _, element := range hud.Emulated {
+		fmt.Fprintf(&b, "| %v | ✓ |\n", hud.Name(element))
+	}
+	for _, element := range hud.Unemulated {
+		fmt.Fprintf(&b, "| %v | ✗ |\n", hud.Name(element))
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+// Command itemcheck cross-checks the two item mapping modes of every protocol: the JSON required item list and
+// the item runtime ID NBT used in direct mode. It lists every item of which the runtime ID or component flag
+// differs between the two, and exits with status 1 if any differences were found, so that the stale file can
+// be replaced.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/oomph-ac/new-mv/mapping"
+	"github.com/oomph-ac/new-mv/protocols/latest"
+	"github.com/oomph-ac/new-mv/protocols/v630"
+	"github.com/oomph-ac/new-mv/protocols/v649"
+	"github.com/oomph-ac/new-mv/protocols/v662"
+	"github.com/oomph-ac/new-mv/protocols/v671"
+	"github.com/oomph-ac/new-mv/protocols/v685"
+	"github.com/oomph-ac/new-mv/protocols/v686"
+	"github.com/oomph-ac/new-mv/protocols/v712"
+	"github.com/oomph-ac/new-mv/protocols/v729"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// versions holds the item mapping constructors of the versions checked, by the name of their package.
+var versions = []struct {
+	name       string
+	newMapping func(direct bool) (mapping.Item, error)
+}{
+	{"v630", v630.NewItemMapping},
+	{"v649", v649.NewItemMapping},
+	{"v662", v662.NewItemMapping},
+	{"v671", v671.NewItemMapping},
+	{"v685", v685.NewItemMapping},
+	{"v686", v686.NewItemMapping},
+	{"v712", v712.NewItemMapping},
+	{"v729", v729.NewItemMapping},
+	{"latest", latest.NewItemMapping},
+}
+
+func main() {
+	// The NBT of older versions only holds runtime IDs, so component flags are only compared when asked to.
+	components := flag.Bool("components", false, "also compare whether items are component based")
+	flag.Parse()
+
+	failed := false
+	for _, version := range versions {
+		json, err := version.newMapping(false)
+		if err != nil {
+			log.Fatalf("%v: load JSON item mapping: %v", version.name, err)
+		}
+		nbt, err := version.newMapping(true)
+		if err != nil {
+			log.Fatalf("%v: load NBT item mapping: %v", version.name, err)
+		}
+		differences := compare(json, nbt, *components)
+		for _, difference := range differences {
+			fmt.Printf("%v: %v\n", version.name, difference)
+		}
+		failed = failed || len(differences) > 0
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// compare returns the differences between the JSON and NBT item mappings passed.
+func compare(json, nbt mapping.Item, components bool) []string {
+	jsonEntries, nbtEntries := entriesByName(json.Entries()), entriesByName(nbt.Entries())
+
+	var differences []string
+	for name, a := range jsonEntries {
+		b, ok := nbtEntries[name]
+		switch {
+		case !ok:
+			differences = append(differences, fmt.Sprintf("%v only in JSON (runtime ID %v)", name, a.RuntimeID))
+		case a.RuntimeID != b.RuntimeID:
+			differences = append(differences, fmt.Sprintf("%v has runtime ID %v in JSON and %v in NBT", name, a.RuntimeID, b.RuntimeID))
+		case components && a.ComponentBased != b.ComponentBased:
+			differences = append(differences, fmt.Sprintf("%v is component based %v in JSON and %v in NBT", name, a.ComponentBased, b.ComponentBased))
+		}
+	}
+	for name, b := range nbtEntries {
+		if _, ok := jsonEntries[name]; !ok {
+			differences = append(differences, fmt.Sprintf("%v only in NBT (runtime ID %v)", name, b.RuntimeID))
+		}
+	}
+	slices.Sort(differences)
+	return differences
+}
+
+// entriesByName returns the entries passed keyed by their name.
+func entriesByName(entries []protocol.ItemEntry) map[string]protocol.ItemEntry {
+	m := make(map[string]protocol.ItemEntry, len(entries))
+	for _, entry := range entries {
+		m[entry.Name] = entry
+	}
+	return m
+}
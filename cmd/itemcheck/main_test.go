@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestItemMappingsMatch(t *testing.T) {
+	for _, version := range versions {
+		t.Run(version.name, func(t *testing.T) {
+			json, err := version.newMapping(false)
+			if err != nil {
+				t.Fatalf("load JSON item mapping: %v", err)
+			}
+			nbt, err := version.newMapping(true)
+			if err != nil {
+				t.Fatalf("load NBT item mapping: %v", err)
+			}
+			for _, difference := range compare(json, nbt, false) {
+				t.Error(difference)
+			}
+		})
+	}
+}
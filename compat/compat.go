@@ -0,0 +1,131 @@
+// Package compat decides what happens to packets of the latest version that a legacy version has no packet
+// for. Every protocol declares the packets it cannot represent, and applies a Policy to them when converting
+// packets sent by the server.
+package compat
+
+//go:generate go run ../cmd/compat -o ../COMPATIBILITY.md
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/oomph-ac/new-mv/metrics"
+	"github.com/oomph-ac/new-mv/trace"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// Policy is what happens to a packet that the version of a client has no packet for.
+type Policy int
+
+const (
+	// Drop drops the packet silently.
+	Drop Policy = iota
+	// DropAndLog drops the packet and reports it to the tracer of the protocol.
+	DropAndLog
+	// Substitute replaces the packet with the packets returned by the substitute of the protocol for it. If
+	// the protocol has no substitute for the packet, it is dropped and reported like with DropAndLog.
+	Substitute
+	// Disconnect replaces the packet with a Disconnect packet naming it, after which the client closes the
+	// connection. The packets following it are dropped.
+	Disconnect
+)
+
+// SubstituteFunc returns the packets sent to a client in place of the packet passed, which the version of the
+// client has no packet for.
+type SubstituteFunc func(pk packet.Packet, conn *minecraft.Conn) []packet.Packet
+
+// Handler applies a Policy to the packets that a single protocol version has no packet for.
+type Handler struct {
+	policy      Policy
+	pool        packet.Pool
+	substitutes map[uint32]SubstituteFunc
+}
+
+// NewHandler returns a Handler applying the policy passed to the packets that are not in the pool passed,
+// which holds the packets the clients of the version can decode.
+func NewHandler(policy Policy, pool packet.Pool, substitutes map[uint32]SubstituteFunc) *Handler {
+	return &Handler{policy: policy, pool: pool, substitutes: substitutes}
+}
+
+// WithPolicy returns a copy of the Handler applying the policy passed instead.
+func (h *Handler) WithPolicy(policy Policy) *Handler {
+	return &Handler{policy: policy, pool: h.pool, substitutes: h.substitutes}
+}
+
+// WithPool returns a copy of the Handler applying its policy to the packets that are not in the pool passed.
+func (h *Handler) WithPool(pool packet.Pool) *Handler {
+	return &Handler{policy: h.policy, pool: pool, substitutes: h.substitutes}
+}
+
+// WithSubstitute returns a copy of the Handler that substitutes the packet with the ID passed using the
+// function passed, replacing any substitute it previously had.
+func (h *Handler) WithSubstitute(id uint32, substitute SubstituteFunc) *Handler {
+	substitutes := maps.Clone(h.substitutes)
+	if substitutes == nil {
+		substitutes = make(map[uint32]SubstituteFunc)
+	}
+	substitutes[id] = substitute
+	return &Handler{policy: h.policy, pool: h.pool, substitutes: substitutes}
+}
+
+// Apply applies the policy of the Handler to the packets passed, which are about to be sent over the
+// connection passed, and returns the packets that should be sent instead. Every packet the policy is applied
+// to is reported to the tracer and counted by the reporter passed by the outcome of the policy.
+// If the client is to be disconnected, the Disconnect packet to send after the packets returned is returned
+// too. It is a packet of the latest version, which must still be converted for the client.
+func (h *Handler) Apply(pks []packet.Packet, conn *minecraft.Conn, tracer *trace.Tracer, reporter *metrics.Reporter) ([]packet.Packet, *packet.Disconnect) {
+	result := make([]packet.Packet, 0, len(pks))
+	for _, pk := range pks {
+		if _, ok := h.pool[pk.ID()]; ok {
+			result = append(result, pk)
+			continue
+		}
+		switch h.policy {
+		case Drop:
+			reporter.Add(metrics.UnsupportedPacketsDropped, 1)
+		case DropAndLog:
+			tracer.Fallback(conn, "dropped unsupported packet", "packet", Name(pk))
+			reporter.Add(metrics.UnsupportedPacketsDropped, 1)
+		case Substitute:
+			if substitute, ok := h.substitutes[pk.ID()]; ok {
+				result = append(result, substitute(pk, conn)...)
+				reporter.Add(metrics.UnsupportedPacketsSubstituted, 1)
+				continue
+			}
+			tracer.Fallback(conn, "dropped unsupported packet without substitute", "packet", Name(pk))
+			reporter.Add(metrics.UnsupportedPacketsDropped, 1)
+		case Disconnect:
+			reporter.Add(metrics.UnsupportedPacketsDisconnected, 1)
+			tracer.Fallback(conn, "disconnecting client for unsupported packet", "packet", Name(pk))
+			return result, &packet.Disconnect{
+				Message: fmt.Sprintf("The server sent a %v packet, which is not supported by your version of the game.", Name(pk)),
+			}
+		}
+	}
+	return result, nil
+}
+
+// Unsupported returns the IDs of the packets of the latest version sent by servers that are not in the pool
+// passed, sorted in ascending order.
+func Unsupported(pool packet.Pool) []uint32 {
+	var ids []uint32
+	for id := range packet.NewServerPool() {
+		if _, ok := pool[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Name returns the name of the packet passed without its package, such as MovementEffect.
+func Name(pk packet.Packet) string {
+	t := reflect.TypeOf(pk)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
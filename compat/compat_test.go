@@ -0,0 +1,126 @@
+package compat
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/oomph-ac/new-mv/metrics"
+	"github.com/oomph-ac/new-mv/trace"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// counters is a metrics.Metrics that keeps the counters added to it.
+type counters map[string]int64
+
+func (c counters) Add(_ int32, name string, delta int64) { c[name] += delta }
+func (c counters) Observe(_ int32, _ string, _ float64)  {}
+
+// pool holds the packets supported by the version in the tests: Text, but not MovementEffect or CameraAimAssist.
+var pool = packet.Pool{packet.IDText: func() packet.Packet { return &packet.Text{} }}
+
+// substituteEffect replaces a MovementEffect packet with a Text packet.
+func substituteEffect(packet.Packet, *minecraft.Conn) []packet.Packet {
+	return []packet.Packet{&packet.Text{Message: "substitute"}}
+}
+
+func TestHandlerApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     Policy
+		want       []packet.Packet
+		disconnect string
+		counters   counters
+		// logged holds the messages expected to be reported to the tracer.
+		logged []string
+	}{
+		{
+			name:     "drop",
+			policy:   Drop,
+			want:     []packet.Packet{&packet.Text{Message: "before"}, &packet.Text{Message: "after"}},
+			counters: counters{metrics.UnsupportedPacketsDropped: 2},
+		},
+		{
+			name:     "drop and log",
+			policy:   DropAndLog,
+			want:     []packet.Packet{&packet.Text{Message: "before"}, &packet.Text{Message: "after"}},
+			counters: counters{metrics.UnsupportedPacketsDropped: 2},
+			logged:   []string{"dropped unsupported packet packet=MovementEffect", "dropped unsupported packet packet=CameraAimAssist"},
+		},
+		{
+			name:     "substitute",
+			policy:   Substitute,
+			want:     []packet.Packet{&packet.Text{Message: "before"}, &packet.Text{Message: "substitute"}, &packet.Text{Message: "after"}},
+			counters: counters{metrics.UnsupportedPacketsSubstituted: 1, metrics.UnsupportedPacketsDropped: 1},
+			logged:   []string{"dropped unsupported packet without substitute packet=CameraAimAssist"},
+		},
+		{
+			name:       "disconnect",
+			policy:     Disconnect,
+			want:       []packet.Packet{&packet.Text{Message: "before"}},
+			disconnect: "The server sent a MovementEffect packet, which is not supported by your version of the game.",
+			counters:   counters{metrics.UnsupportedPacketsDisconnected: 1},
+			logged:     []string{"disconnecting client for unsupported packet packet=MovementEffect"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf, c := bytes.NewBuffer(nil), make(counters)
+			tracer := trace.New(slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey || a.Key == slog.LevelKey {
+					return slog.Attr{}
+				}
+				return a
+			}})), 0)
+			h := NewHandler(test.policy, pool, nil).WithSubstitute(packet.IDMovementEffect, substituteEffect)
+
+			pks, disconnect := h.Apply([]packet.Packet{
+				&packet.Text{Message: "before"},
+				&packet.MovementEffect{},
+				&packet.CameraAimAssist{},
+				&packet.Text{Message: "after"},
+			}, nil, tracer, metrics.NewReporter(c, 630))
+			if !reflect.DeepEqual(pks, test.want) {
+				t.Errorf("got packets %#v, expected %#v", pks, test.want)
+			}
+			if disconnect == nil && test.disconnect != "" || disconnect != nil && disconnect.Message != test.disconnect {
+				t.Errorf("got disconnect %#v, expected message %q", disconnect, test.disconnect)
+			}
+			if !reflect.DeepEqual(c, test.counters) {
+				t.Errorf("got counters %v, expected %v", c, test.counters)
+			}
+
+			var logged []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				if line != "" {
+					logged = append(logged, strings.ReplaceAll(strings.TrimPrefix(line, "msg="), `"`, ""))
+				}
+			}
+			if !reflect.DeepEqual(logged, test.logged) {
+				t.Errorf("logged %q, expected %q", logged, test.logged)
+			}
+		})
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	ids := Unsupported(packet.NewServerPool())
+	if len(ids) != 0 {
+		t.Fatalf("latest server pool has unsupported packets %v", ids)
+	}
+	ids = Unsupported(pool)
+	if len(ids) != len(packet.NewServerPool())-1 {
+		t.Fatalf("got %v unsupported packets, expected all but Text", len(ids))
+	}
+	for i, id := range ids {
+		if id == packet.IDText {
+			t.Fatalf("supported packet Text reported as unsupported")
+		}
+		if i > 0 && ids[i-1] >= id {
+			t.Fatalf("unsupported packets not sorted: %v", ids)
+		}
+	}
+}
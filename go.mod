@@ -7,6 +7,7 @@ toolchain go1.23.2
 require (
 	github.com/df-mc/dragonfly v0.9.18-0.20240814140312-13b68f1ec242
 	github.com/df-mc/worldupgrader v1.0.18
+	github.com/go-gl/mathgl v1.1.0
 	github.com/google/uuid v1.6.0
 	github.com/rogpeppe/go-internal v1.12.0
 	github.com/samber/lo v1.38.1
@@ -21,7 +22,6 @@ require (
 	github.com/brentp/intintmap v0.0.0-20190211203843-30dc0ade9af9 // indirect
 	github.com/df-mc/goleveldb v1.1.9 // indirect
 	github.com/gameparrot/goquery v0.2.0 // indirect
-	github.com/go-gl/mathgl v1.1.0 // indirect
 	github.com/go-jose/go-jose/v3 v3.0.3 // indirect
 	github.com/golang/snappy v0.0.4 // indirect
 	github.com/klauspost/compress v1.17.11 // indirect
@@ -14,10 +14,11 @@ type StateHash struct {
 	Name, Properties string
 }
 
-// HashState produces a hash for the block properties held by the blockState.
-func HashState(state blockupgrader.BlockState) StateHash {
+// HashState produces a hash for the block properties held by the blockState. An error is returned if a property
+// has a type that block states cannot hold.
+func HashState(state blockupgrader.BlockState) (StateHash, error) {
 	if state.Properties == nil {
-		return StateHash{Name: state.Name}
+		return StateHash{Name: state.Name}, nil
 	}
 	keys := make([]string, 0, len(state.Properties))
 	for k := range state.Properties {
@@ -44,10 +45,8 @@ func HashState(state blockupgrader.BlockState) StateHash {
 		case string:
 			b.WriteString(v)
 		default:
-			// If block encoding is broken, we want to find out as soon as possible. This saves a lot of time
-			// debugging in-game.
-			panic(fmt.Sprintf("invalid block property type %T for property %v", v, k))
+			return StateHash{}, fmt.Errorf("invalid block property type %T for property %v of %v", v, k, state.Name)
 		}
 	}
-	return StateHash{Name: state.Name, Properties: b.String()}
+	return StateHash{Name: state.Name, Properties: b.String()}, nil
 }
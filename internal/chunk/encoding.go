@@ -125,6 +125,20 @@ func (b BlockPaletteEncoding) Decode(buf *bytes.Buffer) (uint32, error) {
 	return v, nil
 }
 
+// WithBlockMapping returns the encodings passed using the block mapping passed instead of their own. Encodings
+// that do not encode block states are returned unchanged.
+func WithBlockMapping(e Encoding, pe PaletteEncoding, block mapping.Block) (Encoding, PaletteEncoding) {
+	if n, ok := e.(NetworkPersistentEncoding); ok {
+		n.block = block
+		e = n
+	}
+	if b, ok := pe.(BlockPaletteEncoding); ok {
+		b.block = block
+		pe = b
+	}
+	return e, pe
+}
+
 // NewNetworkPersistentEncoding returns a new NetworkPersistentEncoding using the block and version passed.
 func NewNetworkPersistentEncoding(block mapping.Block, version int32) NetworkPersistentEncoding {
 	return NetworkPersistentEncoding{block: block, version: version}
@@ -151,9 +165,8 @@ func (n NetworkPersistentEncoding) EncodePalette(buf *bytes.Buffer, p *Palette,
 func (n NetworkPersistentEncoding) DecodePalette(buf *bytes.Buffer, blockSize paletteSize, _ PaletteEncoding) (*Palette, error) {
 	var paletteCount int32 = 1
 	if blockSize != 0 {
-		err := protocol.Varint32(buf, &paletteCount)
-		if err != nil {
-			panic(err)
+		if err := protocol.Varint32(buf, &paletteCount); err != nil {
+			return nil, fmt.Errorf("error reading palette entry count: %w", err)
 		}
 		if paletteCount <= 0 {
 			return nil, fmt.Errorf("invalid palette entry count %v", paletteCount)
@@ -0,0 +1,90 @@
+package component
+
+// change is a single change to the layout of item component data, made in the game version with the protocol ID
+// it holds. Its downgrade function rewrites data in the new layout to the layout used before that version.
+type change struct {
+	protocol  int32
+	downgrade func(components map[string]any)
+}
+
+// changes holds all known changes to the layout of item component data, ordered from new to old so that data is
+// downgraded one version at a time.
+var changes = []change{
+	// 1.21.20 moved the use duration of items into the new minecraft:use_modifiers component and introduced
+	// several components that older clients fail to parse.
+	{protocol: 712, downgrade: downgradeUseModifiers},
+	{protocol: 712, downgrade: strip("minecraft:damage_absorption", "minecraft:durability_sensor")},
+	// 1.20.80 changed the minecraft:icon property to hold a map of textures instead of a single texture.
+	{protocol: 671, downgrade: downgradeIcon},
+}
+
+// Downgrader returns a function that downgrades item component data sent by a server on the latest version to
+// the layout expected by a client with the protocol ID passed. The data passed is modified in place.
+func Downgrader(protocol int32) func(data map[string]any) map[string]any {
+	return func(data map[string]any) map[string]any {
+		components, ok := data["components"].(map[string]any)
+		if !ok {
+			return data
+		}
+		for _, c := range changes {
+			if protocol < c.protocol {
+				c.downgrade(components)
+			}
+		}
+		return data
+	}
+}
+
+// strip returns a downgrade function that removes the components with the names passed.
+func strip(names ...string) func(components map[string]any) {
+	return func(components map[string]any) {
+		for _, name := range names {
+			delete(components, name)
+		}
+	}
+}
+
+// downgradeUseModifiers moves the use duration held in the minecraft:use_modifiers component back into the item
+// properties, where it is held in ticks rather than seconds.
+func downgradeUseModifiers(components map[string]any) {
+	modifiers, ok := components["minecraft:use_modifiers"].(map[string]any)
+	if !ok {
+		return
+	}
+	delete(components, "minecraft:use_modifiers")
+
+	duration, ok := modifiers["use_duration"].(float32)
+	if !ok {
+		return
+	}
+	properties := itemProperties(components)
+	if _, ok := properties["use_duration"]; !ok {
+		properties["use_duration"] = int32(duration * 20)
+	}
+}
+
+// downgradeIcon changes the minecraft:icon item property from a map of textures to the single default texture.
+func downgradeIcon(components map[string]any) {
+	properties := itemProperties(components)
+	icon, ok := properties["minecraft:icon"].(map[string]any)
+	if !ok {
+		return
+	}
+	textures, ok := icon["textures"].(map[string]any)
+	if !ok {
+		return
+	}
+	if texture, ok := textures["default"].(string); ok {
+		properties["minecraft:icon"] = map[string]any{"texture": texture}
+	}
+}
+
+// itemProperties returns the item_properties map of the components passed, creating it if it does not exist.
+func itemProperties(components map[string]any) map[string]any {
+	properties, ok := components["item_properties"].(map[string]any)
+	if !ok {
+		properties = make(map[string]any)
+		components["item_properties"] = properties
+	}
+	return properties
+}
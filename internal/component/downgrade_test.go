@@ -0,0 +1,80 @@
+package component
+
+import (
+	"reflect"
+	"testing"
+)
+
+// latestComponents returns item component data in the layout of the latest version.
+func latestComponents() map[string]any {
+	return map[string]any{"components": map[string]any{
+		"minecraft:use_modifiers":     map[string]any{"use_duration": float32(1.6)},
+		"minecraft:damage_absorption": map[string]any{},
+		"minecraft:durability_sensor": map[string]any{},
+		"item_properties": map[string]any{
+			"minecraft:icon": map[string]any{"textures": map[string]any{"default": "apple"}},
+		},
+	}}
+}
+
+func TestDowngrader(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol int32
+		data     map[string]any
+		want     map[string]any
+	}{
+		{
+			name:     "latest layout kept",
+			protocol: 712,
+			data:     latestComponents(),
+			want:     latestComponents(),
+		},
+		{
+			name:     "use modifiers moved and new components stripped",
+			protocol: 686,
+			data:     latestComponents(),
+			want: map[string]any{"components": map[string]any{
+				"item_properties": map[string]any{
+					"use_duration":   int32(32),
+					"minecraft:icon": map[string]any{"textures": map[string]any{"default": "apple"}},
+				},
+			}},
+		},
+		{
+			name:     "icon downgraded to single texture",
+			protocol: 662,
+			data:     latestComponents(),
+			want: map[string]any{"components": map[string]any{
+				"item_properties": map[string]any{
+					"use_duration":   int32(32),
+					"minecraft:icon": map[string]any{"texture": "apple"},
+				},
+			}},
+		},
+		{
+			name:     "use duration of item properties kept",
+			protocol: 686,
+			data: map[string]any{"components": map[string]any{
+				"minecraft:use_modifiers": map[string]any{"use_duration": float32(1.6)},
+				"item_properties":         map[string]any{"use_duration": int32(10)},
+			}},
+			want: map[string]any{"components": map[string]any{
+				"item_properties": map[string]any{"use_duration": int32(10)},
+			}},
+		},
+		{
+			name:     "data without components kept",
+			protocol: 630,
+			data:     map[string]any{"id": int16(1)},
+			want:     map[string]any{"id": int16(1)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Downgrader(test.protocol)(test.data); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("downgraded to %v, expected %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+// Package convert converts packets between protocol versions by copying their fields by name. It is used for
+// the packets that a protocol has no explicit conversion for, which are those sent in the direction opposite
+// to the one a protocol usually converts, such as packets sent by a server of an older version to a client
+// of the latest version.
+//
+// Fields are copied as follows:
+//   - Fields with the same name and type are copied as they are.
+//   - Numeric fields of different types are converted, and structs, slices, arrays, maps and pointers are
+//     copied element by element.
+//   - Fields that the source has no field with the same name for, or of which the type cannot be converted,
+//     are left at their zero value. This includes fields of types with unexported fields, such as
+//     protocol.Optional, that differ between the versions.
+//
+// Fields that older versions sent in another place than the latest version, such as the PackURLs of
+// ResourcePacksInfo, are moved to that place after the other fields were copied. Fields of the latest version
+// that older versions derive from other fields, such as the CameraOrientation of PlayerAuthInput, are dropped
+// without being reported.
+//
+// The fields of the source that hold a value but could not be copied are passed to the function given to
+// Packets, so that conversions losing data can be found and given an explicit conversion.
+package convert
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+var (
+	// latestServerPool holds the packets of the latest version sent by servers.
+	latestServerPool = packet.NewServerPool()
+	// latestClientPool holds the packets of the latest version sent by clients.
+	latestClientPool = packet.NewClientPool()
+
+	// moved holds the functions moving the fields of packets of older versions that the latest version holds
+	// in another place, by the type of the latest packet and the name of the field of the older packet. Each is
+	// called with the converted packet and the value of the field after the other fields were copied.
+	moved = map[reflect.Type]map[string]func(dst packet.Packet, src reflect.Value){
+		reflect.TypeOf(&packet.ResourcePacksInfo{}): {
+			"PackURLs": func(dst packet.Packet, src reflect.Value) {
+				pk := dst.(*packet.ResourcePacksInfo)
+				if urls, ok := src.Interface().([]protocol.PackURL); ok {
+					pk.TexturePacks = DownloadURLs(pk.TexturePacks, urls)
+				}
+			},
+		},
+	}
+	// derived holds the names of the fields of packets of the latest version that older versions derive from
+	// other fields of the packet, by the type of the latest packet.
+	derived = map[reflect.Type][]string{
+		reflect.TypeOf(&packet.PlayerAuthInput{}): {"InteractPitch", "InteractYaw", "CameraOrientation"},
+	}
+)
+
+// LatestPool returns the pool of the packets of the latest version. If listener is true, the packets sent by
+// clients are returned, and otherwise those sent by servers.
+func LatestPool(listener bool) packet.Pool {
+	if listener {
+		return latestClientPool
+	}
+	return latestServerPool
+}
+
+// Packets converts the packets passed to the types of the packets with the same IDs in the pool passed.
+// Packets that already have those types are returned as they are, while packets of which the ID is not in the
+// pool are left out. If lost is not nil, it is called once for every field of a packet that held a value but
+// could not be copied, with the path of the field, such as "TexturePacks[].DownloadURL", and once with an
+// empty path for every packet that was left out.
+func Packets(pks []packet.Packet, pool packet.Pool, lost func(pk packet.Packet, field string)) []packet.Packet {
+	result := make([]packet.Packet, 0, len(pks))
+	for _, pk := range pks {
+		newPacket, ok := pool[pk.ID()]
+		if !ok {
+			if lost != nil {
+				lost(pk, "")
+			}
+			continue
+		}
+		target := newPacket()
+		if reflect.TypeOf(target) == reflect.TypeOf(pk) {
+			result = append(result, pk)
+			continue
+		}
+		c := copier{lost: make(map[string]struct{})}
+		src := reflect.ValueOf(pk).Elem()
+		c.copyValue(reflect.ValueOf(target).Elem(), src, "")
+		for field, move := range moved[reflect.TypeOf(target)] {
+			if value := src.FieldByName(field); value.IsValid() {
+				move(target, value)
+				delete(c.lost, field)
+			}
+		}
+		for _, field := range derived[reflect.TypeOf(pk)] {
+			delete(c.lost, field)
+		}
+		if lost != nil {
+			fields := make([]string, 0, len(c.lost))
+			for field := range c.lost {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			for _, field := range fields {
+				lost(pk, field)
+			}
+		}
+		result = append(result, target)
+	}
+	return result
+}
+
+// DownloadURLs returns the texture packs passed with their download URLs set to those of the pack URLs passed,
+// which is how versions before 1.21.30 sent them. The URL of a pack is found by the UUID and version of the pack,
+// joined by an underscore.
+func DownloadURLs(packs []protocol.TexturePackInfo, urls []protocol.PackURL) []protocol.TexturePackInfo {
+	byPack := make(map[string]string, len(urls))
+	for _, url := range urls {
+		byPack[url.UUIDVersion] = url.URL
+	}
+	for i, pack := range packs {
+		if url, ok := byPack[pack.UUID+"_"+pack.Version]; ok {
+			packs[i].DownloadURL = url
+		}
+	}
+	return packs
+}
+
+// copier copies values of one version to those of another, recording the paths of the fields that could not be
+// copied.
+type copier struct {
+	lost map[string]struct{}
+}
+
+// copyValue copies the value src, found at the path passed, to dst, which must be settable, converting it to
+// the type of dst where possible.
+func (c copier) copyValue(dst, src reflect.Value, path string) {
+	if src.Type() == dst.Type() {
+		dst.Set(src)
+		return
+	}
+	if numeric(dst.Kind()) && numeric(src.Kind()) || dst.Kind() == src.Kind() && basic(dst.Kind()) {
+		dst.Set(src.Convert(dst.Type()))
+		return
+	}
+	switch dst.Kind() {
+	case reflect.Struct:
+		if src.Kind() != reflect.Struct {
+			break
+		}
+		c.copyFields(dst, src, path)
+		for _, field := range reflect.VisibleFields(src.Type()) {
+			if field.Anonymous || src.FieldByIndex(field.Index).IsZero() {
+				continue
+			}
+			if !field.IsExported() {
+				// Unexported fields are never copied between types that differ, even if they have the same
+				// name, such as those of protocol.Optional.
+				c.lose(path)
+				continue
+			}
+			if _, ok := dst.Type().FieldByName(field.Name); !ok {
+				c.lose(join(path, field.Name))
+			}
+		}
+		return
+	case reflect.Slice:
+		if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+			break
+		}
+		if src.Kind() == reflect.Slice && src.IsNil() {
+			return
+		}
+		s := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			c.copyValue(s.Index(i), src.Index(i), path+"[]")
+		}
+		dst.Set(s)
+		return
+	case reflect.Array:
+		if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+			break
+		}
+		for i := 0; i < min(dst.Len(), src.Len()); i++ {
+			c.copyValue(dst.Index(i), src.Index(i), path+"[]")
+		}
+		for i := dst.Len(); i < src.Len(); i++ {
+			if !src.Index(i).IsZero() {
+				c.lose(path + "[]")
+			}
+		}
+		return
+	case reflect.Pointer:
+		if src.Kind() != reflect.Pointer {
+			break
+		}
+		if src.IsNil() {
+			return
+		}
+		v := reflect.New(dst.Type().Elem())
+		c.copyValue(v.Elem(), src.Elem(), path)
+		dst.Set(v)
+		return
+	case reflect.Map:
+		if src.Kind() != reflect.Map {
+			break
+		}
+		if src.IsNil() {
+			return
+		}
+		m := reflect.MakeMapWithSize(dst.Type(), src.Len())
+		for iter := src.MapRange(); iter.Next(); {
+			k, v := reflect.New(dst.Type().Key()).Elem(), reflect.New(dst.Type().Elem()).Elem()
+			c.copyValue(k, iter.Key(), path+"[]")
+			c.copyValue(v, iter.Value(), path+"[]")
+			m.SetMapIndex(k, v)
+		}
+		dst.Set(m)
+		return
+	case reflect.Interface:
+		if src.Kind() == reflect.Interface && !src.IsNil() && src.Elem().Type().AssignableTo(dst.Type()) {
+			dst.Set(src.Elem())
+			return
+		}
+	}
+	if !src.IsZero() {
+		c.lose(path)
+	}
+}
+
+// copyFields copies the fields of the struct src, found at the path passed, to the fields with the same names of
+// the struct dst.
+func (c copier) copyFields(dst, src reflect.Value, path string) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if srcField := src.FieldByName(field.Name); srcField.IsValid() {
+			c.copyValue(dst.Field(i), srcField, join(path, field.Name))
+		} else if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			// The fields of the embedded struct may be found in the source struct directly.
+			c.copyFields(dst.Field(i), src, path)
+		}
+	}
+}
+
+// lose records that the field at the path passed held a value that could not be copied.
+func (c copier) lose(path string) {
+	c.lost[path] = struct{}{}
+}
+
+// join returns the path of the field with the name passed in the struct at the path passed.
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// numeric checks if the kind passed is an integer or floating point kind.
+func numeric(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
+// basic checks if the kind passed is a kind of which values of different types may always be converted to
+// each other, such as strings.
+func basic(kind reflect.Kind) bool {
+	return kind == reflect.Bool || kind == reflect.String || numeric(kind)
+}
@@ -0,0 +1,124 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	v712packet "github.com/oomph-ac/new-mv/protocols/v712/packet"
+	"github.com/oomph-ac/new-mv/protocols/v712/types"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// legacyText is a Text packet of a version that had a field the latest version does not have, and lacked one
+// that the latest version has.
+type legacyText struct {
+	TextType   byte
+	SourceName string
+	Message    string
+	Parameters []string
+	Removed    int32
+}
+
+func (*legacyText) ID() uint32 {
+	return packet.IDText
+}
+
+func (*legacyText) Marshal(protocol.IO) {}
+
+// unknownPacket is a packet with an ID that the latest version has no packet for.
+type unknownPacket struct{}
+
+func (*unknownPacket) ID() uint32 {
+	return 0xfff
+}
+
+func (*unknownPacket) Marshal(protocol.IO) {}
+
+func TestPacketsCopiesByName(t *testing.T) {
+	src := &legacyText{TextType: packet.TextTypeChat, SourceName: "a", Message: "b", Parameters: []string{"c"}}
+	pks := Packets([]packet.Packet{src}, LatestPool(false), func(pk packet.Packet, field string) {
+		t.Errorf("%v of %T reported lost, expected every field to be copied", field, pk)
+	})
+	if len(pks) != 1 {
+		t.Fatalf("got %v packets, expected 1", len(pks))
+	}
+	text, ok := pks[0].(*packet.Text)
+	if !ok {
+		t.Fatalf("converted to %T, expected *packet.Text", pks[0])
+	}
+	if text.TextType != src.TextType || text.SourceName != src.SourceName || text.Message != src.Message || !reflect.DeepEqual(text.Parameters, src.Parameters) {
+		t.Fatalf("fields not copied: %+v", text)
+	}
+}
+
+func TestPacketsReportsLostFields(t *testing.T) {
+	var lost []string
+	report := func(pk packet.Packet, field string) {
+		lost = append(lost, field)
+	}
+	Packets([]packet.Packet{&legacyText{Message: "a", Removed: 1}}, LatestPool(false), report)
+	if !reflect.DeepEqual(lost, []string{"Removed"}) {
+		t.Fatalf("reported %q lost, expected Removed", lost)
+	}
+
+	// Fields without a value are not lost, and packets without equivalent are reported with an empty path.
+	lost = nil
+	pks := Packets([]packet.Packet{&legacyText{Message: "a"}, &unknownPacket{}}, LatestPool(false), report)
+	if len(pks) != 1 || !reflect.DeepEqual(lost, []string{""}) {
+		t.Fatalf("got %v packets and reported %q lost, expected 1 packet and a lost packet", len(pks), lost)
+	}
+}
+
+func TestDownloadURLs(t *testing.T) {
+	packs := DownloadURLs([]protocol.TexturePackInfo{{UUID: "a", Version: "1.0.0"}, {UUID: "b", Version: "1.0.0"}}, []protocol.PackURL{
+		{UUIDVersion: "a_1.0.0", URL: "https://example.com/a.zip"},
+		{UUIDVersion: "b_2.0.0", URL: "https://example.com/b.zip"},
+	})
+	if packs[0].DownloadURL != "https://example.com/a.zip" {
+		t.Errorf("pack a has download URL %q", packs[0].DownloadURL)
+	}
+	if packs[1].DownloadURL != "" {
+		t.Errorf("pack b has download URL %q of another version", packs[1].DownloadURL)
+	}
+}
+
+func TestPacketsMovesPackURLs(t *testing.T) {
+	var lost []string
+	pks := Packets([]packet.Packet{&v712packet.ResourcePacksInfo{
+		TexturePackRequired: true,
+		BehaviourPacks:      []types.TexturePackInfo{{UUID: "b", Version: "1.0.0"}},
+		TexturePacks:        []types.TexturePackInfo{{UUID: "a", Version: "1.0.0", Size: 10}},
+		PackURLs:            []protocol.PackURL{{UUIDVersion: "a_1.0.0", URL: "https://example.com/a.zip"}},
+	}}, LatestPool(false), func(pk packet.Packet, field string) {
+		lost = append(lost, field)
+	})
+	info, ok := pks[0].(*packet.ResourcePacksInfo)
+	if !ok {
+		t.Fatalf("converted to %T, expected *packet.ResourcePacksInfo", pks[0])
+	}
+	want := []protocol.TexturePackInfo{{UUID: "a", Version: "1.0.0", Size: 10, DownloadURL: "https://example.com/a.zip"}}
+	if !info.TexturePackRequired || !reflect.DeepEqual(info.TexturePacks, want) {
+		t.Errorf("converted to %+v, expected texture packs %+v", info, want)
+	}
+	// Behaviour packs cannot be sent to clients of the latest version, so they are reported, unlike the URLs.
+	if !reflect.DeepEqual(lost, []string{"BehaviourPacks"}) {
+		t.Errorf("reported %q lost, expected BehaviourPacks", lost)
+	}
+}
+
+func TestPacketsDropsDerivedFields(t *testing.T) {
+	pool := packet.Pool{packet.IDPlayerAuthInput: func() packet.Packet { return &v712packet.PlayerAuthInput{} }}
+	pks := Packets([]packet.Packet{&packet.PlayerAuthInput{
+		Pitch:             30,
+		InteractPitch:     30,
+		InteractYaw:       90,
+		CameraOrientation: mgl32.Vec3{0, -0.5, 0.87},
+	}}, pool, func(pk packet.Packet, field string) {
+		t.Errorf("%v of %T reported lost, expected derived fields to be dropped silently", field, pk)
+	})
+	if input, ok := pks[0].(*v712packet.PlayerAuthInput); !ok || input.Pitch != 30 {
+		t.Fatalf("converted to %+v, expected *v712packet.PlayerAuthInput with its pitch", pks[0])
+	}
+}
@@ -0,0 +1,129 @@
+// Package hud emulates the SetHud packet for clients of 1.20.50, the only supported version older than 1.20.60,
+// which added the packet. Those clients have no packet to hide or show elements of their HUD.
+//
+// Only some elements can be approximated with packets those clients do understand:
+//   - ProgressBar: boss bars are hidden while the element is hidden, and shown again as they currently look
+//     once it is reset. Boss bars shown by the server while the element is hidden are held back until then.
+//   - ItemText: the title and action bar are cleared when the element is hidden, and action bar, tip and
+//     popup messages sent while the element is hidden are dropped.
+//
+// All other elements, listed in Unemulated, are part of the client's own interface and are left as they are.
+// Hiding or resetting them is reported to the tracer, as are SetHud packets with an unknown visibility.
+package hud
+
+import (
+	"github.com/oomph-ac/new-mv/internal/session"
+	"github.com/oomph-ac/new-mv/trace"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// Emulated holds the HUD elements that are approximated for clients without SetHud.
+var Emulated = []int32{
+	packet.HudElementProgressBar,
+	packet.HudElementItemText,
+}
+
+// Unemulated holds the HUD elements that cannot be approximated for clients without SetHud. Hiding or
+// showing them has no effect on those clients.
+var Unemulated = []int32{
+	packet.HudElementPaperDoll,
+	packet.HudElementArmour,
+	packet.HudElementToolTips,
+	packet.HudElementTouchControls,
+	packet.HudElementCrosshair,
+	packet.HudElementHotBar,
+	packet.HudElementHealth,
+	packet.HudElementHunger,
+	packet.HudElementAirBubbles,
+	packet.HudElementHorseHealth,
+	packet.HudElementStatusEffects,
+}
+
+// names holds the names of the HUD elements, indexed by their ID.
+var names = map[int32]string{
+	packet.HudElementPaperDoll:     "PaperDoll",
+	packet.HudElementArmour:        "Armour",
+	packet.HudElementToolTips:      "ToolTips",
+	packet.HudElementTouchControls: "TouchControls",
+	packet.HudElementCrosshair:     "Crosshair",
+	packet.HudElementHotBar:        "HotBar",
+	packet.HudElementHealth:        "Health",
+	packet.HudElementProgressBar:   "ProgressBar",
+	packet.HudElementHunger:        "Hunger",
+	packet.HudElementAirBubbles:    "AirBubbles",
+	packet.HudElementHorseHealth:   "HorseHealth",
+	packet.HudElementStatusEffects: "StatusEffects",
+	packet.HudElementItemText:      "ItemText",
+}
+
+// Name returns the name of the HUD element passed, such as ProgressBar.
+func Name(element int32) string {
+	return names[element]
+}
+
+// Emulate replaces the SetHud packets in the latest packets passed with the packets approximating them, and
+// drops the packets that would show an element that is currently hidden. The state passed must already have
+// observed the packets. The elements that cannot be emulated are reported to the tracer passed.
+func Emulate(pks []packet.Packet, state *session.State, conn *minecraft.Conn, tracer *trace.Tracer) []packet.Packet {
+	result := make([]packet.Packet, 0, len(pks))
+	for _, pk := range pks {
+		switch pk := pk.(type) {
+		case *packet.SetHud:
+			result = append(result, emulate(pk, state, conn, tracer)...)
+		case *packet.BossEvent:
+			if pk.EventType == packet.BossEventShow && state.HudHidden(packet.HudElementProgressBar) {
+				continue
+			}
+			result = append(result, pk)
+		case *packet.SetTitle:
+			if pk.ActionType == packet.TitleActionSetActionBar && state.HudHidden(packet.HudElementItemText) {
+				continue
+			}
+			result = append(result, pk)
+		case *packet.Text:
+			if (pk.TextType == packet.TextTypeTip || pk.TextType == packet.TextTypePopup) && state.HudHidden(packet.HudElementItemText) {
+				continue
+			}
+			result = append(result, pk)
+		default:
+			result = append(result, pk)
+		}
+	}
+	return result
+}
+
+// emulate returns the packets approximating the SetHud packet passed.
+func emulate(pk *packet.SetHud, state *session.State, conn *minecraft.Conn, tracer *trace.Tracer) []packet.Packet {
+	var hide bool
+	switch pk.Visibility {
+	case packet.HudVisibilityHide:
+		hide = true
+	case packet.HudVisibilityReset:
+		// Every element is shown by default, so resetting an element shows it.
+		hide = false
+	default:
+		tracer.Fallback(conn, "dropped SetHud with unknown visibility", "visibility", pk.Visibility)
+		return nil
+	}
+	var pks []packet.Packet
+	for _, element := range pk.Elements {
+		switch element {
+		case packet.HudElementProgressBar:
+			for _, bar := range state.BossBars() {
+				if hide {
+					pks = append(pks, &packet.BossEvent{BossEntityUniqueID: bar.BossEntityUniqueID, EventType: packet.BossEventHide})
+				} else {
+					pks = append(pks, &bar)
+				}
+			}
+		case packet.HudElementItemText:
+			if hide {
+				pks = append(pks, &packet.SetTitle{ActionType: packet.TitleActionClear})
+			}
+		default:
+			tracer.Fallback(conn, "HUD element cannot be emulated", "element", Name(element), "visibility", pk.Visibility)
+		}
+	}
+	return pks
+}
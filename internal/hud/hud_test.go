@@ -0,0 +1,93 @@
+package hud
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/oomph-ac/new-mv/internal/session"
+	"github.com/oomph-ac/new-mv/trace"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// bar is a boss bar shown to the client before the SetHud packets of the tests are sent.
+var bar = &packet.BossEvent{BossEntityUniqueID: 7, EventType: packet.BossEventShow, BossBarTitle: "Boss", HealthPercentage: 0.5}
+
+func TestEmulate(t *testing.T) {
+	tests := []struct {
+		name string
+		// before holds the packets sent before the packet emulated.
+		before []packet.Packet
+		pk     packet.Packet
+		want   []packet.Packet
+		// fallback is the message expected to be reported to the tracer, if any.
+		fallback string
+	}{
+		{
+			name: "hide progress bar",
+			pk:   &packet.SetHud{Elements: []int32{packet.HudElementProgressBar}, Visibility: packet.HudVisibilityHide},
+			want: []packet.Packet{&packet.BossEvent{BossEntityUniqueID: 7, EventType: packet.BossEventHide}},
+		},
+		{
+			name:   "reset progress bar",
+			before: []packet.Packet{&packet.SetHud{Elements: []int32{packet.HudElementProgressBar}, Visibility: packet.HudVisibilityHide}},
+			pk:     &packet.SetHud{Elements: []int32{packet.HudElementProgressBar}, Visibility: packet.HudVisibilityReset},
+			want:   []packet.Packet{bar},
+		},
+		{
+			name:   "boss bar held back while hidden",
+			before: []packet.Packet{&packet.SetHud{Elements: []int32{packet.HudElementProgressBar}, Visibility: packet.HudVisibilityHide}},
+			pk:     &packet.BossEvent{BossEntityUniqueID: 8, EventType: packet.BossEventShow},
+		},
+		{
+			name: "hide item text",
+			pk:   &packet.SetHud{Elements: []int32{packet.HudElementItemText}, Visibility: packet.HudVisibilityHide},
+			want: []packet.Packet{&packet.SetTitle{ActionType: packet.TitleActionClear}},
+		},
+		{
+			name:   "tip dropped while item text hidden",
+			before: []packet.Packet{&packet.SetHud{Elements: []int32{packet.HudElementItemText}, Visibility: packet.HudVisibilityHide}},
+			pk:     &packet.Text{TextType: packet.TextTypeTip, Message: "tip"},
+		},
+		{
+			name:   "chat kept while item text hidden",
+			before: []packet.Packet{&packet.SetHud{Elements: []int32{packet.HudElementItemText}, Visibility: packet.HudVisibilityHide}},
+			pk:     &packet.Text{TextType: packet.TextTypeChat, Message: "chat"},
+			want:   []packet.Packet{&packet.Text{TextType: packet.TextTypeChat, Message: "chat"}},
+		},
+		{
+			name:     "unemulated element",
+			pk:       &packet.SetHud{Elements: []int32{packet.HudElementHotBar}, Visibility: packet.HudVisibilityHide},
+			fallback: "HUD element cannot be emulated",
+		},
+		{
+			name:     "unknown visibility",
+			pk:       &packet.SetHud{Elements: []int32{packet.HudElementItemText}, Visibility: 5},
+			fallback: "dropped SetHud with unknown visibility",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var log bytes.Buffer
+			tracer := trace.New(slog.New(slog.NewTextHandler(&log, nil)), 1)
+
+			state := session.NewState()
+			for _, pk := range append([]packet.Packet{bar}, test.before...) {
+				state.Observe(pk)
+			}
+			state.Observe(test.pk)
+			got := Emulate([]packet.Packet{test.pk}, state, nil, tracer)
+			if len(got) != len(test.want) || len(got) > 0 && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("emulated as %#v, expected %#v", got, test.want)
+			}
+			if test.fallback == "" && log.Len() != 0 {
+				t.Errorf("unexpected fallback reported: %v", log.String())
+			}
+			if test.fallback != "" && !strings.Contains(log.String(), test.fallback) {
+				t.Errorf("reported %q, expected %q", log.String(), test.fallback)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Package input reconstructs the fields of the latest PlayerAuthInput packet that older clients do not send.
+//
+// Clients older than 1.21.40 send their look rotation (Pitch, Yaw and HeadYaw), but no separate interaction
+// rotation or camera orientation. When upgrading their PlayerAuthInput packets, the following latest fields are
+// synthesized rather than read from the client:
+//   - InteractPitch and InteractYaw: the rotation the client interacts with. Older clients always interact in
+//     the direction they look in, so this is the look rotation, or the rotation of the gaze direction when the
+//     client is playing in virtual reality.
+//   - CameraOrientation: the unit vector pointing in the direction of the interaction rotation.
+//   - InputData flags newer than the client: always unset, as the client has no way to report them. The flags
+//     the client does know are moved to their latest bits using the FlagLayout of its version.
+//
+// All other fields are copied from what the client sent.
+package input
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// InteractRotation returns the pitch and yaw that a client, which only sends its look rotation and, in virtual
+// reality, its gaze direction, interacts with.
+func InteractRotation(pitch, headYaw float32, playMode uint32, gazeDirection mgl32.Vec3) (interactPitch, interactYaw float32) {
+	if playMode != packet.PlayModeReality || gazeDirection.Len() == 0 {
+		return pitch, headYaw
+	}
+	dir := gazeDirection.Normalize()
+	interactPitch = float32(-math.Asin(float64(dir.Y())) * 180 / math.Pi)
+	interactYaw = float32(math.Atan2(float64(-dir.X()), float64(dir.Z())) * 180 / math.Pi)
+	return interactPitch, interactYaw
+}
+
+// CameraOrientation returns the unit vector pointing in the direction of the pitch and yaw passed.
+func CameraOrientation(pitch, yaw float32) mgl32.Vec3 {
+	pitchRad, yawRad := float64(pitch)*math.Pi/180, float64(yaw)*math.Pi/180
+	return mgl32.Vec3{
+		float32(-math.Sin(yawRad) * math.Cos(pitchRad)),
+		float32(-math.Sin(pitchRad)),
+		float32(math.Cos(yawRad) * math.Cos(pitchRad)),
+	}
+}
@@ -0,0 +1,477 @@
+package input
+
+import (
+	v630packet "github.com/oomph-ac/new-mv/protocols/v630/packet"
+	v649packet "github.com/oomph-ac/new-mv/protocols/v649/packet"
+	v662packet "github.com/oomph-ac/new-mv/protocols/v662/packet"
+	v671packet "github.com/oomph-ac/new-mv/protocols/v671/packet"
+	v685packet "github.com/oomph-ac/new-mv/protocols/v685/packet"
+	v686packet "github.com/oomph-ac/new-mv/protocols/v686/packet"
+	v712packet "github.com/oomph-ac/new-mv/protocols/v712/packet"
+	v729packet "github.com/oomph-ac/new-mv/protocols/v729/packet"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// FlagLayout maps the flags of the PlayerAuthInput InputData of a version of the protocol to the flags of the
+// latest InputData. Each key is an InputFlag constant of the packet package of the version, and its value is
+// the latest InputFlag constant of the same flag.
+type FlagLayout map[uint64]uint64
+
+// Upgrade returns the latest InputData holding the flags set in the InputData passed, which was sent by a
+// client of the version of the layout. Bits that the layout has no entry for are never set by such clients and
+// are dropped, so that they can never be mistaken for flags the client does not know.
+func (l FlagLayout) Upgrade(inputData uint64) uint64 {
+	var latest uint64
+	for flag, latestFlag := range l {
+		if inputData&flag != 0 {
+			latest |= latestFlag
+		}
+	}
+	return latest
+}
+
+// Downgrade returns the InputData of the version of the layout holding the flags set in the latest InputData
+// passed, which is sent to a server of the version by a client of the latest version. Flags that the version
+// does not know are dropped.
+func (l FlagLayout) Downgrade(inputData uint64) uint64 {
+	var legacy uint64
+	for flag, latestFlag := range l {
+		if inputData&latestFlag != 0 {
+			legacy |= flag
+		}
+	}
+	return legacy
+}
+
+var (
+	// Flags630 is the layout of the InputData of 1.20.50 (protocol 630).
+	Flags630 = FlagLayout{
+		v630packet.InputFlagAscend:                  packet.InputFlagAscend,
+		v630packet.InputFlagDescend:                 packet.InputFlagDescend,
+		v630packet.InputFlagNorthJump:               packet.InputFlagNorthJump,
+		v630packet.InputFlagJumpDown:                packet.InputFlagJumpDown,
+		v630packet.InputFlagSprintDown:              packet.InputFlagSprintDown,
+		v630packet.InputFlagChangeHeight:            packet.InputFlagChangeHeight,
+		v630packet.InputFlagJumping:                 packet.InputFlagJumping,
+		v630packet.InputFlagAutoJumpingInWater:      packet.InputFlagAutoJumpingInWater,
+		v630packet.InputFlagSneaking:                packet.InputFlagSneaking,
+		v630packet.InputFlagSneakDown:               packet.InputFlagSneakDown,
+		v630packet.InputFlagUp:                      packet.InputFlagUp,
+		v630packet.InputFlagDown:                    packet.InputFlagDown,
+		v630packet.InputFlagLeft:                    packet.InputFlagLeft,
+		v630packet.InputFlagRight:                   packet.InputFlagRight,
+		v630packet.InputFlagUpLeft:                  packet.InputFlagUpLeft,
+		v630packet.InputFlagUpRight:                 packet.InputFlagUpRight,
+		v630packet.InputFlagWantUp:                  packet.InputFlagWantUp,
+		v630packet.InputFlagWantDown:                packet.InputFlagWantDown,
+		v630packet.InputFlagWantDownSlow:            packet.InputFlagWantDownSlow,
+		v630packet.InputFlagWantUpSlow:              packet.InputFlagWantUpSlow,
+		v630packet.InputFlagSprinting:               packet.InputFlagSprinting,
+		v630packet.InputFlagAscendBlock:             packet.InputFlagAscendBlock,
+		v630packet.InputFlagDescendBlock:            packet.InputFlagDescendBlock,
+		v630packet.InputFlagSneakToggleDown:         packet.InputFlagSneakToggleDown,
+		v630packet.InputFlagPersistSneak:            packet.InputFlagPersistSneak,
+		v630packet.InputFlagStartSprinting:          packet.InputFlagStartSprinting,
+		v630packet.InputFlagStopSprinting:           packet.InputFlagStopSprinting,
+		v630packet.InputFlagStartSneaking:           packet.InputFlagStartSneaking,
+		v630packet.InputFlagStopSneaking:            packet.InputFlagStopSneaking,
+		v630packet.InputFlagStartSwimming:           packet.InputFlagStartSwimming,
+		v630packet.InputFlagStopSwimming:            packet.InputFlagStopSwimming,
+		v630packet.InputFlagStartJumping:            packet.InputFlagStartJumping,
+		v630packet.InputFlagStartGliding:            packet.InputFlagStartGliding,
+		v630packet.InputFlagStopGliding:             packet.InputFlagStopGliding,
+		v630packet.InputFlagPerformItemInteraction:  packet.InputFlagPerformItemInteraction,
+		v630packet.InputFlagPerformBlockActions:     packet.InputFlagPerformBlockActions,
+		v630packet.InputFlagPerformItemStackRequest: packet.InputFlagPerformItemStackRequest,
+		v630packet.InputFlagHandledTeleport:         packet.InputFlagHandledTeleport,
+		v630packet.InputFlagEmoting:                 packet.InputFlagEmoting,
+		v630packet.InputFlagMissedSwing:             packet.InputFlagMissedSwing,
+		v630packet.InputFlagStartCrawling:           packet.InputFlagStartCrawling,
+		v630packet.InputFlagStopCrawling:            packet.InputFlagStopCrawling,
+		v630packet.InputFlagStartFlying:             packet.InputFlagStartFlying,
+		v630packet.InputFlagStopFlying:              packet.InputFlagStopFlying,
+		v630packet.InputFlagClientAckServerData:     packet.InputFlagClientAckServerData,
+	}
+	// Flags649 is the layout of the InputData of 1.20.60 (protocol 649), which added the flag set while the
+	// client predicts the movement of its vehicle and the paddling flags.
+	Flags649 = FlagLayout{
+		v649packet.InputFlagAscend:                  packet.InputFlagAscend,
+		v649packet.InputFlagDescend:                 packet.InputFlagDescend,
+		v649packet.InputFlagNorthJump:               packet.InputFlagNorthJump,
+		v649packet.InputFlagJumpDown:                packet.InputFlagJumpDown,
+		v649packet.InputFlagSprintDown:              packet.InputFlagSprintDown,
+		v649packet.InputFlagChangeHeight:            packet.InputFlagChangeHeight,
+		v649packet.InputFlagJumping:                 packet.InputFlagJumping,
+		v649packet.InputFlagAutoJumpingInWater:      packet.InputFlagAutoJumpingInWater,
+		v649packet.InputFlagSneaking:                packet.InputFlagSneaking,
+		v649packet.InputFlagSneakDown:               packet.InputFlagSneakDown,
+		v649packet.InputFlagUp:                      packet.InputFlagUp,
+		v649packet.InputFlagDown:                    packet.InputFlagDown,
+		v649packet.InputFlagLeft:                    packet.InputFlagLeft,
+		v649packet.InputFlagRight:                   packet.InputFlagRight,
+		v649packet.InputFlagUpLeft:                  packet.InputFlagUpLeft,
+		v649packet.InputFlagUpRight:                 packet.InputFlagUpRight,
+		v649packet.InputFlagWantUp:                  packet.InputFlagWantUp,
+		v649packet.InputFlagWantDown:                packet.InputFlagWantDown,
+		v649packet.InputFlagWantDownSlow:            packet.InputFlagWantDownSlow,
+		v649packet.InputFlagWantUpSlow:              packet.InputFlagWantUpSlow,
+		v649packet.InputFlagSprinting:               packet.InputFlagSprinting,
+		v649packet.InputFlagAscendBlock:             packet.InputFlagAscendBlock,
+		v649packet.InputFlagDescendBlock:            packet.InputFlagDescendBlock,
+		v649packet.InputFlagSneakToggleDown:         packet.InputFlagSneakToggleDown,
+		v649packet.InputFlagPersistSneak:            packet.InputFlagPersistSneak,
+		v649packet.InputFlagStartSprinting:          packet.InputFlagStartSprinting,
+		v649packet.InputFlagStopSprinting:           packet.InputFlagStopSprinting,
+		v649packet.InputFlagStartSneaking:           packet.InputFlagStartSneaking,
+		v649packet.InputFlagStopSneaking:            packet.InputFlagStopSneaking,
+		v649packet.InputFlagStartSwimming:           packet.InputFlagStartSwimming,
+		v649packet.InputFlagStopSwimming:            packet.InputFlagStopSwimming,
+		v649packet.InputFlagStartJumping:            packet.InputFlagStartJumping,
+		v649packet.InputFlagStartGliding:            packet.InputFlagStartGliding,
+		v649packet.InputFlagStopGliding:             packet.InputFlagStopGliding,
+		v649packet.InputFlagPerformItemInteraction:  packet.InputFlagPerformItemInteraction,
+		v649packet.InputFlagPerformBlockActions:     packet.InputFlagPerformBlockActions,
+		v649packet.InputFlagPerformItemStackRequest: packet.InputFlagPerformItemStackRequest,
+		v649packet.InputFlagHandledTeleport:         packet.InputFlagHandledTeleport,
+		v649packet.InputFlagEmoting:                 packet.InputFlagEmoting,
+		v649packet.InputFlagMissedSwing:             packet.InputFlagMissedSwing,
+		v649packet.InputFlagStartCrawling:           packet.InputFlagStartCrawling,
+		v649packet.InputFlagStopCrawling:            packet.InputFlagStopCrawling,
+		v649packet.InputFlagStartFlying:             packet.InputFlagStartFlying,
+		v649packet.InputFlagStopFlying:              packet.InputFlagStopFlying,
+		v649packet.InputFlagClientAckServerData:     packet.InputFlagClientAckServerData,
+		v649packet.InputFlagClientPredictedVehicle:  packet.InputFlagClientPredictedVehicle,
+		v649packet.InputFlagPaddlingLeft:            packet.InputFlagPaddlingLeft,
+		v649packet.InputFlagPaddlingRight:           packet.InputFlagPaddlingRight,
+	}
+	// Flags662 is the layout of the InputData of 1.20.70 (protocol 662), which added no flags.
+	Flags662 = FlagLayout{
+		v662packet.InputFlagAscend:                  packet.InputFlagAscend,
+		v662packet.InputFlagDescend:                 packet.InputFlagDescend,
+		v662packet.InputFlagNorthJump:               packet.InputFlagNorthJump,
+		v662packet.InputFlagJumpDown:                packet.InputFlagJumpDown,
+		v662packet.InputFlagSprintDown:              packet.InputFlagSprintDown,
+		v662packet.InputFlagChangeHeight:            packet.InputFlagChangeHeight,
+		v662packet.InputFlagJumping:                 packet.InputFlagJumping,
+		v662packet.InputFlagAutoJumpingInWater:      packet.InputFlagAutoJumpingInWater,
+		v662packet.InputFlagSneaking:                packet.InputFlagSneaking,
+		v662packet.InputFlagSneakDown:               packet.InputFlagSneakDown,
+		v662packet.InputFlagUp:                      packet.InputFlagUp,
+		v662packet.InputFlagDown:                    packet.InputFlagDown,
+		v662packet.InputFlagLeft:                    packet.InputFlagLeft,
+		v662packet.InputFlagRight:                   packet.InputFlagRight,
+		v662packet.InputFlagUpLeft:                  packet.InputFlagUpLeft,
+		v662packet.InputFlagUpRight:                 packet.InputFlagUpRight,
+		v662packet.InputFlagWantUp:                  packet.InputFlagWantUp,
+		v662packet.InputFlagWantDown:                packet.InputFlagWantDown,
+		v662packet.InputFlagWantDownSlow:            packet.InputFlagWantDownSlow,
+		v662packet.InputFlagWantUpSlow:              packet.InputFlagWantUpSlow,
+		v662packet.InputFlagSprinting:               packet.InputFlagSprinting,
+		v662packet.InputFlagAscendBlock:             packet.InputFlagAscendBlock,
+		v662packet.InputFlagDescendBlock:            packet.InputFlagDescendBlock,
+		v662packet.InputFlagSneakToggleDown:         packet.InputFlagSneakToggleDown,
+		v662packet.InputFlagPersistSneak:            packet.InputFlagPersistSneak,
+		v662packet.InputFlagStartSprinting:          packet.InputFlagStartSprinting,
+		v662packet.InputFlagStopSprinting:           packet.InputFlagStopSprinting,
+		v662packet.InputFlagStartSneaking:           packet.InputFlagStartSneaking,
+		v662packet.InputFlagStopSneaking:            packet.InputFlagStopSneaking,
+		v662packet.InputFlagStartSwimming:           packet.InputFlagStartSwimming,
+		v662packet.InputFlagStopSwimming:            packet.InputFlagStopSwimming,
+		v662packet.InputFlagStartJumping:            packet.InputFlagStartJumping,
+		v662packet.InputFlagStartGliding:            packet.InputFlagStartGliding,
+		v662packet.InputFlagStopGliding:             packet.InputFlagStopGliding,
+		v662packet.InputFlagPerformItemInteraction:  packet.InputFlagPerformItemInteraction,
+		v662packet.InputFlagPerformBlockActions:     packet.InputFlagPerformBlockActions,
+		v662packet.InputFlagPerformItemStackRequest: packet.InputFlagPerformItemStackRequest,
+		v662packet.InputFlagHandledTeleport:         packet.InputFlagHandledTeleport,
+		v662packet.InputFlagEmoting:                 packet.InputFlagEmoting,
+		v662packet.InputFlagMissedSwing:             packet.InputFlagMissedSwing,
+		v662packet.InputFlagStartCrawling:           packet.InputFlagStartCrawling,
+		v662packet.InputFlagStopCrawling:            packet.InputFlagStopCrawling,
+		v662packet.InputFlagStartFlying:             packet.InputFlagStartFlying,
+		v662packet.InputFlagStopFlying:              packet.InputFlagStopFlying,
+		v662packet.InputFlagClientAckServerData:     packet.InputFlagClientAckServerData,
+		v662packet.InputFlagClientPredictedVehicle:  packet.InputFlagClientPredictedVehicle,
+		v662packet.InputFlagPaddlingLeft:            packet.InputFlagPaddlingLeft,
+		v662packet.InputFlagPaddlingRight:           packet.InputFlagPaddlingRight,
+	}
+	// Flags671 is the layout of the InputData of 1.20.80 (protocol 671), which added the flag set while block
+	// breaking is delayed.
+	Flags671 = FlagLayout{
+		v671packet.InputFlagAscend:                    packet.InputFlagAscend,
+		v671packet.InputFlagDescend:                   packet.InputFlagDescend,
+		v671packet.InputFlagNorthJump:                 packet.InputFlagNorthJump,
+		v671packet.InputFlagJumpDown:                  packet.InputFlagJumpDown,
+		v671packet.InputFlagSprintDown:                packet.InputFlagSprintDown,
+		v671packet.InputFlagChangeHeight:              packet.InputFlagChangeHeight,
+		v671packet.InputFlagJumping:                   packet.InputFlagJumping,
+		v671packet.InputFlagAutoJumpingInWater:        packet.InputFlagAutoJumpingInWater,
+		v671packet.InputFlagSneaking:                  packet.InputFlagSneaking,
+		v671packet.InputFlagSneakDown:                 packet.InputFlagSneakDown,
+		v671packet.InputFlagUp:                        packet.InputFlagUp,
+		v671packet.InputFlagDown:                      packet.InputFlagDown,
+		v671packet.InputFlagLeft:                      packet.InputFlagLeft,
+		v671packet.InputFlagRight:                     packet.InputFlagRight,
+		v671packet.InputFlagUpLeft:                    packet.InputFlagUpLeft,
+		v671packet.InputFlagUpRight:                   packet.InputFlagUpRight,
+		v671packet.InputFlagWantUp:                    packet.InputFlagWantUp,
+		v671packet.InputFlagWantDown:                  packet.InputFlagWantDown,
+		v671packet.InputFlagWantDownSlow:              packet.InputFlagWantDownSlow,
+		v671packet.InputFlagWantUpSlow:                packet.InputFlagWantUpSlow,
+		v671packet.InputFlagSprinting:                 packet.InputFlagSprinting,
+		v671packet.InputFlagAscendBlock:               packet.InputFlagAscendBlock,
+		v671packet.InputFlagDescendBlock:              packet.InputFlagDescendBlock,
+		v671packet.InputFlagSneakToggleDown:           packet.InputFlagSneakToggleDown,
+		v671packet.InputFlagPersistSneak:              packet.InputFlagPersistSneak,
+		v671packet.InputFlagStartSprinting:            packet.InputFlagStartSprinting,
+		v671packet.InputFlagStopSprinting:             packet.InputFlagStopSprinting,
+		v671packet.InputFlagStartSneaking:             packet.InputFlagStartSneaking,
+		v671packet.InputFlagStopSneaking:              packet.InputFlagStopSneaking,
+		v671packet.InputFlagStartSwimming:             packet.InputFlagStartSwimming,
+		v671packet.InputFlagStopSwimming:              packet.InputFlagStopSwimming,
+		v671packet.InputFlagStartJumping:              packet.InputFlagStartJumping,
+		v671packet.InputFlagStartGliding:              packet.InputFlagStartGliding,
+		v671packet.InputFlagStopGliding:               packet.InputFlagStopGliding,
+		v671packet.InputFlagPerformItemInteraction:    packet.InputFlagPerformItemInteraction,
+		v671packet.InputFlagPerformBlockActions:       packet.InputFlagPerformBlockActions,
+		v671packet.InputFlagPerformItemStackRequest:   packet.InputFlagPerformItemStackRequest,
+		v671packet.InputFlagHandledTeleport:           packet.InputFlagHandledTeleport,
+		v671packet.InputFlagEmoting:                   packet.InputFlagEmoting,
+		v671packet.InputFlagMissedSwing:               packet.InputFlagMissedSwing,
+		v671packet.InputFlagStartCrawling:             packet.InputFlagStartCrawling,
+		v671packet.InputFlagStopCrawling:              packet.InputFlagStopCrawling,
+		v671packet.InputFlagStartFlying:               packet.InputFlagStartFlying,
+		v671packet.InputFlagStopFlying:                packet.InputFlagStopFlying,
+		v671packet.InputFlagClientAckServerData:       packet.InputFlagClientAckServerData,
+		v671packet.InputFlagClientPredictedVehicle:    packet.InputFlagClientPredictedVehicle,
+		v671packet.InputFlagPaddlingLeft:              packet.InputFlagPaddlingLeft,
+		v671packet.InputFlagPaddlingRight:             packet.InputFlagPaddlingRight,
+		v671packet.InputFlagBlockBreakingDelayEnabled: packet.InputFlagBlockBreakingDelayEnabled,
+	}
+	// Flags685 is the layout of the InputData of 1.21.0 (protocol 685), which added the collision flags and the
+	// diagonal backwards movement flags.
+	Flags685 = FlagLayout{
+		v685packet.InputFlagAscend:                    packet.InputFlagAscend,
+		v685packet.InputFlagDescend:                   packet.InputFlagDescend,
+		v685packet.InputFlagNorthJump:                 packet.InputFlagNorthJump,
+		v685packet.InputFlagJumpDown:                  packet.InputFlagJumpDown,
+		v685packet.InputFlagSprintDown:                packet.InputFlagSprintDown,
+		v685packet.InputFlagChangeHeight:              packet.InputFlagChangeHeight,
+		v685packet.InputFlagJumping:                   packet.InputFlagJumping,
+		v685packet.InputFlagAutoJumpingInWater:        packet.InputFlagAutoJumpingInWater,
+		v685packet.InputFlagSneaking:                  packet.InputFlagSneaking,
+		v685packet.InputFlagSneakDown:                 packet.InputFlagSneakDown,
+		v685packet.InputFlagUp:                        packet.InputFlagUp,
+		v685packet.InputFlagDown:                      packet.InputFlagDown,
+		v685packet.InputFlagLeft:                      packet.InputFlagLeft,
+		v685packet.InputFlagRight:                     packet.InputFlagRight,
+		v685packet.InputFlagUpLeft:                    packet.InputFlagUpLeft,
+		v685packet.InputFlagUpRight:                   packet.InputFlagUpRight,
+		v685packet.InputFlagWantUp:                    packet.InputFlagWantUp,
+		v685packet.InputFlagWantDown:                  packet.InputFlagWantDown,
+		v685packet.InputFlagWantDownSlow:              packet.InputFlagWantDownSlow,
+		v685packet.InputFlagWantUpSlow:                packet.InputFlagWantUpSlow,
+		v685packet.InputFlagSprinting:                 packet.InputFlagSprinting,
+		v685packet.InputFlagAscendBlock:               packet.InputFlagAscendBlock,
+		v685packet.InputFlagDescendBlock:              packet.InputFlagDescendBlock,
+		v685packet.InputFlagSneakToggleDown:           packet.InputFlagSneakToggleDown,
+		v685packet.InputFlagPersistSneak:              packet.InputFlagPersistSneak,
+		v685packet.InputFlagStartSprinting:            packet.InputFlagStartSprinting,
+		v685packet.InputFlagStopSprinting:             packet.InputFlagStopSprinting,
+		v685packet.InputFlagStartSneaking:             packet.InputFlagStartSneaking,
+		v685packet.InputFlagStopSneaking:              packet.InputFlagStopSneaking,
+		v685packet.InputFlagStartSwimming:             packet.InputFlagStartSwimming,
+		v685packet.InputFlagStopSwimming:              packet.InputFlagStopSwimming,
+		v685packet.InputFlagStartJumping:              packet.InputFlagStartJumping,
+		v685packet.InputFlagStartGliding:              packet.InputFlagStartGliding,
+		v685packet.InputFlagStopGliding:               packet.InputFlagStopGliding,
+		v685packet.InputFlagPerformItemInteraction:    packet.InputFlagPerformItemInteraction,
+		v685packet.InputFlagPerformBlockActions:       packet.InputFlagPerformBlockActions,
+		v685packet.InputFlagPerformItemStackRequest:   packet.InputFlagPerformItemStackRequest,
+		v685packet.InputFlagHandledTeleport:           packet.InputFlagHandledTeleport,
+		v685packet.InputFlagEmoting:                   packet.InputFlagEmoting,
+		v685packet.InputFlagMissedSwing:               packet.InputFlagMissedSwing,
+		v685packet.InputFlagStartCrawling:             packet.InputFlagStartCrawling,
+		v685packet.InputFlagStopCrawling:              packet.InputFlagStopCrawling,
+		v685packet.InputFlagStartFlying:               packet.InputFlagStartFlying,
+		v685packet.InputFlagStopFlying:                packet.InputFlagStopFlying,
+		v685packet.InputFlagClientAckServerData:       packet.InputFlagClientAckServerData,
+		v685packet.InputFlagClientPredictedVehicle:    packet.InputFlagClientPredictedVehicle,
+		v685packet.InputFlagPaddlingLeft:              packet.InputFlagPaddlingLeft,
+		v685packet.InputFlagPaddlingRight:             packet.InputFlagPaddlingRight,
+		v685packet.InputFlagBlockBreakingDelayEnabled: packet.InputFlagBlockBreakingDelayEnabled,
+		v685packet.InputFlagHorizontalCollision:       packet.InputFlagHorizontalCollision,
+		v685packet.InputFlagVerticalCollision:         packet.InputFlagVerticalCollision,
+		v685packet.InputFlagDownLeft:                  packet.InputFlagDownLeft,
+		v685packet.InputFlagDownRight:                 packet.InputFlagDownRight,
+	}
+	// Flags686 is the layout of the InputData of 1.21.2 (protocol 686), which added no flags.
+	Flags686 = FlagLayout{
+		v686packet.InputFlagAscend:                    packet.InputFlagAscend,
+		v686packet.InputFlagDescend:                   packet.InputFlagDescend,
+		v686packet.InputFlagNorthJump:                 packet.InputFlagNorthJump,
+		v686packet.InputFlagJumpDown:                  packet.InputFlagJumpDown,
+		v686packet.InputFlagSprintDown:                packet.InputFlagSprintDown,
+		v686packet.InputFlagChangeHeight:              packet.InputFlagChangeHeight,
+		v686packet.InputFlagJumping:                   packet.InputFlagJumping,
+		v686packet.InputFlagAutoJumpingInWater:        packet.InputFlagAutoJumpingInWater,
+		v686packet.InputFlagSneaking:                  packet.InputFlagSneaking,
+		v686packet.InputFlagSneakDown:                 packet.InputFlagSneakDown,
+		v686packet.InputFlagUp:                        packet.InputFlagUp,
+		v686packet.InputFlagDown:                      packet.InputFlagDown,
+		v686packet.InputFlagLeft:                      packet.InputFlagLeft,
+		v686packet.InputFlagRight:                     packet.InputFlagRight,
+		v686packet.InputFlagUpLeft:                    packet.InputFlagUpLeft,
+		v686packet.InputFlagUpRight:                   packet.InputFlagUpRight,
+		v686packet.InputFlagWantUp:                    packet.InputFlagWantUp,
+		v686packet.InputFlagWantDown:                  packet.InputFlagWantDown,
+		v686packet.InputFlagWantDownSlow:              packet.InputFlagWantDownSlow,
+		v686packet.InputFlagWantUpSlow:                packet.InputFlagWantUpSlow,
+		v686packet.InputFlagSprinting:                 packet.InputFlagSprinting,
+		v686packet.InputFlagAscendBlock:               packet.InputFlagAscendBlock,
+		v686packet.InputFlagDescendBlock:              packet.InputFlagDescendBlock,
+		v686packet.InputFlagSneakToggleDown:           packet.InputFlagSneakToggleDown,
+		v686packet.InputFlagPersistSneak:              packet.InputFlagPersistSneak,
+		v686packet.InputFlagStartSprinting:            packet.InputFlagStartSprinting,
+		v686packet.InputFlagStopSprinting:             packet.InputFlagStopSprinting,
+		v686packet.InputFlagStartSneaking:             packet.InputFlagStartSneaking,
+		v686packet.InputFlagStopSneaking:              packet.InputFlagStopSneaking,
+		v686packet.InputFlagStartSwimming:             packet.InputFlagStartSwimming,
+		v686packet.InputFlagStopSwimming:              packet.InputFlagStopSwimming,
+		v686packet.InputFlagStartJumping:              packet.InputFlagStartJumping,
+		v686packet.InputFlagStartGliding:              packet.InputFlagStartGliding,
+		v686packet.InputFlagStopGliding:               packet.InputFlagStopGliding,
+		v686packet.InputFlagPerformItemInteraction:    packet.InputFlagPerformItemInteraction,
+		v686packet.InputFlagPerformBlockActions:       packet.InputFlagPerformBlockActions,
+		v686packet.InputFlagPerformItemStackRequest:   packet.InputFlagPerformItemStackRequest,
+		v686packet.InputFlagHandledTeleport:           packet.InputFlagHandledTeleport,
+		v686packet.InputFlagEmoting:                   packet.InputFlagEmoting,
+		v686packet.InputFlagMissedSwing:               packet.InputFlagMissedSwing,
+		v686packet.InputFlagStartCrawling:             packet.InputFlagStartCrawling,
+		v686packet.InputFlagStopCrawling:              packet.InputFlagStopCrawling,
+		v686packet.InputFlagStartFlying:               packet.InputFlagStartFlying,
+		v686packet.InputFlagStopFlying:                packet.InputFlagStopFlying,
+		v686packet.InputFlagClientAckServerData:       packet.InputFlagClientAckServerData,
+		v686packet.InputFlagClientPredictedVehicle:    packet.InputFlagClientPredictedVehicle,
+		v686packet.InputFlagPaddlingLeft:              packet.InputFlagPaddlingLeft,
+		v686packet.InputFlagPaddlingRight:             packet.InputFlagPaddlingRight,
+		v686packet.InputFlagBlockBreakingDelayEnabled: packet.InputFlagBlockBreakingDelayEnabled,
+		v686packet.InputFlagHorizontalCollision:       packet.InputFlagHorizontalCollision,
+		v686packet.InputFlagVerticalCollision:         packet.InputFlagVerticalCollision,
+		v686packet.InputFlagDownLeft:                  packet.InputFlagDownLeft,
+		v686packet.InputFlagDownRight:                 packet.InputFlagDownRight,
+	}
+	// Flags712 is the layout of the InputData of 1.21.20 (protocol 712), which added no flags.
+	Flags712 = FlagLayout{
+		v712packet.InputFlagAscend:                    packet.InputFlagAscend,
+		v712packet.InputFlagDescend:                   packet.InputFlagDescend,
+		v712packet.InputFlagNorthJump:                 packet.InputFlagNorthJump,
+		v712packet.InputFlagJumpDown:                  packet.InputFlagJumpDown,
+		v712packet.InputFlagSprintDown:                packet.InputFlagSprintDown,
+		v712packet.InputFlagChangeHeight:              packet.InputFlagChangeHeight,
+		v712packet.InputFlagJumping:                   packet.InputFlagJumping,
+		v712packet.InputFlagAutoJumpingInWater:        packet.InputFlagAutoJumpingInWater,
+		v712packet.InputFlagSneaking:                  packet.InputFlagSneaking,
+		v712packet.InputFlagSneakDown:                 packet.InputFlagSneakDown,
+		v712packet.InputFlagUp:                        packet.InputFlagUp,
+		v712packet.InputFlagDown:                      packet.InputFlagDown,
+		v712packet.InputFlagLeft:                      packet.InputFlagLeft,
+		v712packet.InputFlagRight:                     packet.InputFlagRight,
+		v712packet.InputFlagUpLeft:                    packet.InputFlagUpLeft,
+		v712packet.InputFlagUpRight:                   packet.InputFlagUpRight,
+		v712packet.InputFlagWantUp:                    packet.InputFlagWantUp,
+		v712packet.InputFlagWantDown:                  packet.InputFlagWantDown,
+		v712packet.InputFlagWantDownSlow:              packet.InputFlagWantDownSlow,
+		v712packet.InputFlagWantUpSlow:                packet.InputFlagWantUpSlow,
+		v712packet.InputFlagSprinting:                 packet.InputFlagSprinting,
+		v712packet.InputFlagAscendBlock:               packet.InputFlagAscendBlock,
+		v712packet.InputFlagDescendBlock:              packet.InputFlagDescendBlock,
+		v712packet.InputFlagSneakToggleDown:           packet.InputFlagSneakToggleDown,
+		v712packet.InputFlagPersistSneak:              packet.InputFlagPersistSneak,
+		v712packet.InputFlagStartSprinting:            packet.InputFlagStartSprinting,
+		v712packet.InputFlagStopSprinting:             packet.InputFlagStopSprinting,
+		v712packet.InputFlagStartSneaking:             packet.InputFlagStartSneaking,
+		v712packet.InputFlagStopSneaking:              packet.InputFlagStopSneaking,
+		v712packet.InputFlagStartSwimming:             packet.InputFlagStartSwimming,
+		v712packet.InputFlagStopSwimming:              packet.InputFlagStopSwimming,
+		v712packet.InputFlagStartJumping:              packet.InputFlagStartJumping,
+		v712packet.InputFlagStartGliding:              packet.InputFlagStartGliding,
+		v712packet.InputFlagStopGliding:               packet.InputFlagStopGliding,
+		v712packet.InputFlagPerformItemInteraction:    packet.InputFlagPerformItemInteraction,
+		v712packet.InputFlagPerformBlockActions:       packet.InputFlagPerformBlockActions,
+		v712packet.InputFlagPerformItemStackRequest:   packet.InputFlagPerformItemStackRequest,
+		v712packet.InputFlagHandledTeleport:           packet.InputFlagHandledTeleport,
+		v712packet.InputFlagEmoting:                   packet.InputFlagEmoting,
+		v712packet.InputFlagMissedSwing:               packet.InputFlagMissedSwing,
+		v712packet.InputFlagStartCrawling:             packet.InputFlagStartCrawling,
+		v712packet.InputFlagStopCrawling:              packet.InputFlagStopCrawling,
+		v712packet.InputFlagStartFlying:               packet.InputFlagStartFlying,
+		v712packet.InputFlagStopFlying:                packet.InputFlagStopFlying,
+		v712packet.InputFlagClientAckServerData:       packet.InputFlagClientAckServerData,
+		v712packet.InputFlagClientPredictedVehicle:    packet.InputFlagClientPredictedVehicle,
+		v712packet.InputFlagPaddlingLeft:              packet.InputFlagPaddlingLeft,
+		v712packet.InputFlagPaddlingRight:             packet.InputFlagPaddlingRight,
+		v712packet.InputFlagBlockBreakingDelayEnabled: packet.InputFlagBlockBreakingDelayEnabled,
+		v712packet.InputFlagHorizontalCollision:       packet.InputFlagHorizontalCollision,
+		v712packet.InputFlagVerticalCollision:         packet.InputFlagVerticalCollision,
+		v712packet.InputFlagDownLeft:                  packet.InputFlagDownLeft,
+		v712packet.InputFlagDownRight:                 packet.InputFlagDownRight,
+	}
+	// Flags729 is the layout of the InputData of 1.21.30 (protocol 729), which added no flags. 1.21.40 added
+	// the flags following it.
+	Flags729 = FlagLayout{
+		v729packet.InputFlagAscend:                    packet.InputFlagAscend,
+		v729packet.InputFlagDescend:                   packet.InputFlagDescend,
+		v729packet.InputFlagNorthJump:                 packet.InputFlagNorthJump,
+		v729packet.InputFlagJumpDown:                  packet.InputFlagJumpDown,
+		v729packet.InputFlagSprintDown:                packet.InputFlagSprintDown,
+		v729packet.InputFlagChangeHeight:              packet.InputFlagChangeHeight,
+		v729packet.InputFlagJumping:                   packet.InputFlagJumping,
+		v729packet.InputFlagAutoJumpingInWater:        packet.InputFlagAutoJumpingInWater,
+		v729packet.InputFlagSneaking:                  packet.InputFlagSneaking,
+		v729packet.InputFlagSneakDown:                 packet.InputFlagSneakDown,
+		v729packet.InputFlagUp:                        packet.InputFlagUp,
+		v729packet.InputFlagDown:                      packet.InputFlagDown,
+		v729packet.InputFlagLeft:                      packet.InputFlagLeft,
+		v729packet.InputFlagRight:                     packet.InputFlagRight,
+		v729packet.InputFlagUpLeft:                    packet.InputFlagUpLeft,
+		v729packet.InputFlagUpRight:                   packet.InputFlagUpRight,
+		v729packet.InputFlagWantUp:                    packet.InputFlagWantUp,
+		v729packet.InputFlagWantDown:                  packet.InputFlagWantDown,
+		v729packet.InputFlagWantDownSlow:              packet.InputFlagWantDownSlow,
+		v729packet.InputFlagWantUpSlow:                packet.InputFlagWantUpSlow,
+		v729packet.InputFlagSprinting:                 packet.InputFlagSprinting,
+		v729packet.InputFlagAscendBlock:               packet.InputFlagAscendBlock,
+		v729packet.InputFlagDescendBlock:              packet.InputFlagDescendBlock,
+		v729packet.InputFlagSneakToggleDown:           packet.InputFlagSneakToggleDown,
+		v729packet.InputFlagPersistSneak:              packet.InputFlagPersistSneak,
+		v729packet.InputFlagStartSprinting:            packet.InputFlagStartSprinting,
+		v729packet.InputFlagStopSprinting:             packet.InputFlagStopSprinting,
+		v729packet.InputFlagStartSneaking:             packet.InputFlagStartSneaking,
+		v729packet.InputFlagStopSneaking:              packet.InputFlagStopSneaking,
+		v729packet.InputFlagStartSwimming:             packet.InputFlagStartSwimming,
+		v729packet.InputFlagStopSwimming:              packet.InputFlagStopSwimming,
+		v729packet.InputFlagStartJumping:              packet.InputFlagStartJumping,
+		v729packet.InputFlagStartGliding:              packet.InputFlagStartGliding,
+		v729packet.InputFlagStopGliding:               packet.InputFlagStopGliding,
+		v729packet.InputFlagPerformItemInteraction:    packet.InputFlagPerformItemInteraction,
+		v729packet.InputFlagPerformBlockActions:       packet.InputFlagPerformBlockActions,
+		v729packet.InputFlagPerformItemStackRequest:   packet.InputFlagPerformItemStackRequest,
+		v729packet.InputFlagHandledTeleport:           packet.InputFlagHandledTeleport,
+		v729packet.InputFlagEmoting:                   packet.InputFlagEmoting,
+		v729packet.InputFlagMissedSwing:               packet.InputFlagMissedSwing,
+		v729packet.InputFlagStartCrawling:             packet.InputFlagStartCrawling,
+		v729packet.InputFlagStopCrawling:              packet.InputFlagStopCrawling,
+		v729packet.InputFlagStartFlying:               packet.InputFlagStartFlying,
+		v729packet.InputFlagStopFlying:                packet.InputFlagStopFlying,
+		v729packet.InputFlagClientAckServerData:       packet.InputFlagClientAckServerData,
+		v729packet.InputFlagClientPredictedVehicle:    packet.InputFlagClientPredictedVehicle,
+		v729packet.InputFlagPaddlingLeft:              packet.InputFlagPaddlingLeft,
+		v729packet.InputFlagPaddlingRight:             packet.InputFlagPaddlingRight,
+		v729packet.InputFlagBlockBreakingDelayEnabled: packet.InputFlagBlockBreakingDelayEnabled,
+		v729packet.InputFlagHorizontalCollision:       packet.InputFlagHorizontalCollision,
+		v729packet.InputFlagVerticalCollision:         packet.InputFlagVerticalCollision,
+		v729packet.InputFlagDownLeft:                  packet.InputFlagDownLeft,
+		v729packet.InputFlagDownRight:                 packet.InputFlagDownRight,
+	}
+)
@@ -0,0 +1,67 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestFlagLayoutUpgrade(t *testing.T) {
+	tests := []struct {
+		name      string
+		layout    FlagLayout
+		inputData uint64
+		want      uint64
+	}{
+		{
+			// A 1.20.50 client jumping while sprinting forwards.
+			name:      "630 sprint jump",
+			layout:    Flags630,
+			inputData: 0x80110448,
+			want: packet.InputFlagJumpDown | packet.InputFlagJumping | packet.InputFlagUp | packet.InputFlagWantUp |
+				packet.InputFlagSprinting | packet.InputFlagStartJumping,
+		},
+		{
+			// A 1.20.50 client breaking a block while holding an item.
+			name:      "630 block actions",
+			layout:    Flags630,
+			inputData: 0xc00000000,
+			want:      packet.InputFlagPerformItemInteraction | packet.InputFlagPerformBlockActions,
+		},
+		{
+			// 1.20.50 has no flag at bit 45, so a client never sets it and it is dropped.
+			name:      "630 unknown bit",
+			layout:    Flags630,
+			inputData: 0x200000000000,
+			want:      0,
+		},
+		{
+			// A 1.20.60 client paddling a boat it predicts the movement of.
+			name:      "649 paddling",
+			layout:    Flags649,
+			inputData: 0xe00000000000,
+			want:      packet.InputFlagClientPredictedVehicle | packet.InputFlagPaddlingLeft | packet.InputFlagPaddlingRight,
+		},
+		{
+			// A 1.21.30 client walking backwards to the left into a wall.
+			name:      "729 collision",
+			layout:    Flags729,
+			inputData: 0xa000000000800,
+			want:      packet.InputFlagDown | packet.InputFlagHorizontalCollision | packet.InputFlagDownLeft,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.layout.Upgrade(test.inputData); got != test.want {
+				t.Errorf("InputData %#x upgraded to %#x, expected %#x", test.inputData, got, test.want)
+			}
+		})
+	}
+}
+
+func TestFlagLayoutDowngradeDropsUnknownFlags(t *testing.T) {
+	latest := uint64(packet.InputFlagStartFlying | packet.InputFlagClientPredictedVehicle | packet.InputFlagDownRight)
+	if got, want := Flags630.Downgrade(latest), uint64(0x40000000000); got != want {
+		t.Errorf("InputData %#x downgraded to %#x, expected %#x", latest, got, want)
+	}
+}
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"embed"
 	"encoding/json"
+	"fmt"
 	"github.com/df-mc/worldupgrader/blockupgrader"
 	"github.com/sandertv/gophertunnel/minecraft/nbt"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"regexp"
 	"sort"
 	"strconv"
+	"sync"
 )
 
 var (
@@ -40,12 +42,17 @@ type stateHash struct {
 	Metadata uint32
 }
 
-// init ...
-func init() {
+// Load loads the item upgrade schemas and the legacy block states of items embedded in the package. It is called
+// by the functions of the package, which leave items unchanged if loading failed, and returns the error loading
+// them, if any.
+var Load = sync.OnceValue(load)
+
+// load loads the item upgrade schemas and the legacy block states of items.
+func load() error {
 	schemas = make(map[uint16]schema)
 	files, err := schemasFS.ReadDir("schemas")
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("read item upgrade schemas: %w", err)
 	}
 	for _, f := range files {
 		if f.IsDir() {
@@ -62,11 +69,11 @@ func init() {
 
 		buf, err := schemasFS.ReadFile("schemas/" + f.Name())
 		if err != nil {
-			panic(err)
+			return fmt.Errorf("read item upgrade schema %v: %w", f.Name(), err)
 		}
 		var s schema
 		if err = json.Unmarshal(buf, &s); err != nil {
-			panic(err)
+			return fmt.Errorf("decode item upgrade schema %v: %w", f.Name(), err)
 		}
 		schemas[uint16(id)] = s
 	}
@@ -77,10 +84,24 @@ func init() {
 	sort.Sort(sort.Reverse(sort.IntSlice(schemaIDs)))
 
 	if err = json.Unmarshal(rawItemToBlockIdMap, &itemToBlockIdMap); err != nil {
-		panic(err)
+		return fmt.Errorf("decode item to block ID map: %w", err)
+	}
+	if blockStateMap, err = loadBlockStateMap(); err != nil {
+		return fmt.Errorf("decode legacy block state map: %w", err)
 	}
+	return nil
+}
 
-	blockStateMap = make(map[stateHash]blockupgrader.BlockState)
+// loadBlockStateMap decodes the latest block state of every legacy block ID and metadata value.
+func loadBlockStateMap() (m map[stateHash]blockupgrader.BlockState, err error) {
+	defer func() {
+		// The reader panics when the data is malformed.
+		if r := recover(); r != nil {
+			m, err = nil, fmt.Errorf("%v", r)
+		}
+	}()
+
+	m = make(map[stateHash]blockupgrader.BlockState)
 	buf := protocol.NewReader(bytes.NewBuffer(rawblockStateMap), 0, false)
 	var length uint32
 	buf.Varuint32(&length)
@@ -96,15 +117,21 @@ func init() {
 
 			var blockStateRaw map[string]any
 			buf.NBT(&blockStateRaw, nbt.LittleEndian)
-			latestBlockState := blockupgrader.Upgrade(blockupgrader.BlockState{
-				Name:       blockStateRaw["name"].(string),
-				Properties: blockStateRaw["states"].(map[string]any),
-				Version:    blockStateRaw["version"].(int32),
-			})
-			blockStateMap[stateHash{
+			name, _ := blockStateRaw["name"].(string)
+			properties, _ := blockStateRaw["states"].(map[string]any)
+			version, ok := blockStateRaw["version"].(int32)
+			if name == "" || !ok {
+				return nil, fmt.Errorf("invalid block state of %v:%v: %v", legacyStringId, meta, blockStateRaw)
+			}
+			m[stateHash{
 				Name:     legacyStringId,
 				Metadata: meta,
-			}] = latestBlockState
+			}] = blockupgrader.Upgrade(blockupgrader.BlockState{
+				Name:       name,
+				Properties: properties,
+				Version:    version,
+			})
 		}
 	}
+	return m, nil
 }
@@ -13,6 +13,9 @@ type Item struct {
 
 // Upgrade upgrades the given item using the registered item upgrade schemas.
 func Upgrade(item Item, ver uint16) Item {
+	if Load() != nil {
+		return item
+	}
 	version := item.Version
 	for id, s := range schemas {
 		if version > id || id > ver {
@@ -44,6 +47,9 @@ func Upgrade(item Item, ver uint16) Item {
 
 // Downgrade downgrades the given item using the registered item upgrade schemas.
 func Downgrade(item Item, ver uint16) Item {
+	if Load() != nil {
+		return item
+	}
 	for i, id := range schemaIDs {
 		s := schemas[uint16(id)]
 		if uint16(id) > item.Version {
@@ -92,6 +98,9 @@ func Downgrade(item Item, ver uint16) Item {
 }
 
 func BlockStateFromItemName(itemName string, metadata uint32) (blockupgrader.BlockState, bool) {
+	if Load() != nil {
+		return blockupgrader.BlockState{}, false
+	}
 	blockId, ok := itemToBlockIdMap[itemName]
 	if !ok {
 		return blockupgrader.BlockState{}, false
@@ -0,0 +1,82 @@
+// Package movement converts the movement packets added in 1.21.40 to the closest equivalents that older
+// clients understand.
+//
+// Older clients take their movement authority mode from StartGame only, and have no packet that changes it:
+//   - SetMovementAuthority sent before StartGame is applied to the movement settings of StartGame. This only
+//     happens when the packets are passed to the protocol before the connection has started the game, such as
+//     by proxies that buffer the packets of the server while the client joins.
+//   - SetMovementAuthority sent after StartGame, which is every SetMovementAuthority sent by a server over a
+//     regular connection, cannot be applied and is dropped. It cannot be emulated either: the mode decides
+//     whether the client sends PlayerAuthInput and whether it rewinds on CorrectPlayerMovePrediction, neither
+//     of which can be changed by other packets. The mode the client actually runs in remains available through
+//     session.State.MovementAuthority, so that the server can account for it.
+//
+// MovementEffect is approximated for the player itself:
+//   - A glide boost sent to a client running in server authoritative mode with rewind becomes a
+//     CorrectPlayerMovePrediction that resets the player to the position and tick of its last PlayerAuthInput
+//     with the velocity a firework rocket boosts a gliding player to, in the direction the player last looked
+//     in. The client rewinds to that tick and replays its inputs since, so the boost is applied as if it was
+//     predicted by the client itself.
+//   - A glide boost sent to other clients becomes a SetActorMotion setting the same velocity.
+//   - Either way, the boost is applied at once rather than over its duration, as the conversion of a packet
+//     cannot send packets later on.
+//   - Effects on other entities are dropped, as clients do not predict the movement of other entities.
+package movement
+
+import (
+	"github.com/oomph-ac/new-mv/internal/input"
+	"github.com/oomph-ac/new-mv/internal/session"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+const (
+	// glideBoostSpeed is the speed in blocks per tick that a firework rocket boosts a gliding player to.
+	glideBoostSpeed = 1.5
+	// authorityServerWithRewind is the movement authority mode in which the movement of the client is
+	// authoritative on the server, and the client rewinds to the tick of movement corrections sent to it.
+	authorityServerWithRewind = 2
+)
+
+// Downgrade replaces the MovementEffect and SetMovementAuthority packets in the latest packets passed with
+// their closest equivalents, and applies the movement authority set before StartGame to StartGame. The state
+// passed must already have observed the packets.
+func Downgrade(pks []packet.Packet, state *session.State) []packet.Packet {
+	result := make([]packet.Packet, 0, len(pks))
+	for _, pk := range pks {
+		switch pk := pk.(type) {
+		case *packet.StartGame:
+			if authority, ok := state.MovementAuthorityOverride(); ok {
+				pk.PlayerMovementSettings.MovementType = int32(authority)
+			}
+			result = append(result, pk)
+		case *packet.SetMovementAuthority:
+		case *packet.MovementEffect:
+			if pk.Type != packet.MovementEffectTypeGlideBoost || pk.EntityRuntimeID != state.EntityRuntimeID() {
+				continue
+			}
+			result = append(result, glideBoost(pk, state))
+		default:
+			result = append(result, pk)
+		}
+	}
+	return result
+}
+
+// glideBoost returns the packet that applies the glide boost passed to the player itself.
+func glideBoost(pk *packet.MovementEffect, state *session.State) packet.Packet {
+	pitch, yaw := state.Rotation()
+	velocity := input.CameraOrientation(pitch, yaw).Mul(glideBoostSpeed)
+	if position, tick := state.LastInput(); state.MovementAuthority() == authorityServerWithRewind && tick != 0 {
+		return &packet.CorrectPlayerMovePrediction{
+			PredictionType: packet.PredictionTypePlayer,
+			Position:       position,
+			Delta:          velocity,
+			Tick:           tick,
+		}
+	}
+	return &packet.SetActorMotion{
+		EntityRuntimeID: pk.EntityRuntimeID,
+		Velocity:        velocity,
+		Tick:            pk.Tick,
+	}
+}
@@ -0,0 +1,97 @@
+package movement
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/oomph-ac/new-mv/internal/session"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// newState returns a state of a player with the runtime ID 1 that joined in the movement authority mode passed
+// and last sent the PlayerAuthInput passed.
+func newState(authority int32, input *packet.PlayerAuthInput) *session.State {
+	state := session.NewState()
+	start := &packet.StartGame{EntityRuntimeID: 1}
+	start.PlayerMovementSettings.MovementType = authority
+	state.Observe(start)
+	if input != nil {
+		state.Observe(input)
+	}
+	return state
+}
+
+func TestGlideBoostWithRewind(t *testing.T) {
+	input := &packet.PlayerAuthInput{Position: mgl32.Vec3{1, 2, 3}, Tick: 40}
+	pks := Downgrade([]packet.Packet{&packet.MovementEffect{EntityRuntimeID: 1, Type: packet.MovementEffectTypeGlideBoost, Tick: 45}}, newState(authorityServerWithRewind, input))
+	if len(pks) != 1 {
+		t.Fatalf("got %v packets, expected 1", len(pks))
+	}
+	correction, ok := pks[0].(*packet.CorrectPlayerMovePrediction)
+	if !ok {
+		t.Fatalf("glide boost became %T, expected *packet.CorrectPlayerMovePrediction", pks[0])
+	}
+	if correction.Position != input.Position || correction.Tick != input.Tick {
+		t.Fatalf("corrected to %v at tick %v, expected %v at tick %v", correction.Position, correction.Tick, input.Position, input.Tick)
+	}
+	if speed := correction.Delta.Len(); mgl32.Abs(speed-glideBoostSpeed) > 1e-4 {
+		t.Fatalf("boosted to speed %v, expected %v", speed, glideBoostSpeed)
+	}
+}
+
+func TestGlideBoostWithoutRewind(t *testing.T) {
+	for name, state := range map[string]*session.State{
+		"server authority": newState(1, &packet.PlayerAuthInput{Tick: 40}),
+		"no input yet":     newState(authorityServerWithRewind, nil),
+	} {
+		pks := Downgrade([]packet.Packet{&packet.MovementEffect{EntityRuntimeID: 1, Type: packet.MovementEffectTypeGlideBoost, Tick: 45}}, state)
+		if len(pks) != 1 {
+			t.Fatalf("%v: got %v packets, expected 1", name, len(pks))
+		}
+		if motion, ok := pks[0].(*packet.SetActorMotion); !ok || motion.Tick != 45 {
+			t.Fatalf("%v: glide boost became %#v, expected SetActorMotion at tick 45", name, pks[0])
+		}
+	}
+}
+
+func TestSetMovementAuthorityAfterStartGame(t *testing.T) {
+	state := newState(0, nil)
+	authority := &packet.SetMovementAuthority{MovementType: authorityServerWithRewind}
+	state.Observe(authority)
+	if pks := Downgrade([]packet.Packet{authority}, state); len(pks) != 0 {
+		t.Fatalf("SetMovementAuthority became %v packets, expected it to be dropped", len(pks))
+	}
+	if mode := state.MovementAuthority(); mode != 0 {
+		t.Fatalf("client tracked in movement authority mode %v, expected the mode of StartGame", mode)
+	}
+}
+
+func TestStartGameMovementAuthority(t *testing.T) {
+	tests := []struct {
+		name string
+		// pending is the SetMovementAuthority sent before StartGame, if any.
+		pending *packet.SetMovementAuthority
+		want    int32
+	}{
+		{name: "kept without pending authority", want: 1},
+		{name: "overridden by pending authority", pending: &packet.SetMovementAuthority{MovementType: authorityServerWithRewind}, want: authorityServerWithRewind},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := session.NewState()
+			if test.pending != nil {
+				state.Observe(test.pending)
+				if pks := Downgrade([]packet.Packet{test.pending}, state); len(pks) != 0 {
+					t.Fatalf("SetMovementAuthority became %v packets, expected it to be dropped", len(pks))
+				}
+			}
+			start := &packet.StartGame{EntityRuntimeID: 1}
+			start.PlayerMovementSettings.MovementType = 1
+			state.Observe(start)
+			Downgrade([]packet.Packet{start}, state)
+			if start.PlayerMovementSettings.MovementType != test.want {
+				t.Errorf("StartGame has movement type %v, expected %v", start.PlayerMovementSettings.MovementType, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package session
+
+import (
+	"sync"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// defaultEmoteLength is the emote length in ticks used for emotes of which the server has not yet sent a length.
+const defaultEmoteLength = 100
+
+// loadingScreenStage is the stage of the loading screen shown to a client while it changes dimension.
+type loadingScreenStage int
+
+const (
+	// loadingScreenNone means the client is not changing dimension.
+	loadingScreenNone loadingScreenStage = iota
+	// loadingScreenPending means the server sent a ChangeDimension packet but the client has not yet shown
+	// the loading screen for it.
+	loadingScreenPending
+	// loadingScreenShown means the client is showing the loading screen and has not yet finished changing
+	// dimension.
+	loadingScreenShown
+)
+
+// State holds values sent over a connection that older versions of the protocol leave out of their packets.
+// Conversions use it to fill the fields of the latest packets from real data instead of guessing them.
+type State struct {
+	mu sync.Mutex
+
+	started         bool
+	disconnected    bool
+	entityUniqueID  int64
+	entityRuntimeID uint64
+	riding          bool
+	routeToManager  bool
+
+	loadingScreen   loadingScreenStage
+	loadingScreenID protocol.Optional[uint32]
+
+	movementAuthority           byte
+	pendingMovementAuthority    protocol.Optional[byte]
+	movementAuthorityOverridden bool
+	pitch, yaw                  float32
+	position                    mgl32.Vec3
+	inputTick                   uint64
+
+	hiddenHud map[int32]struct{}
+	bossBars  map[int64]packet.BossEvent
+
+	bodyArmour     map[uint64]struct{}
+	emoteLengths   map[string]uint32
+	containerSizes map[uint32]uint32
+	openContainers map[byte]byte
+}
+
+// NewState returns a new State without any values observed.
+func NewState() *State {
+	return &State{
+		bodyArmour:     make(map[uint64]struct{}),
+		emoteLengths:   make(map[string]uint32),
+		containerSizes: make(map[uint32]uint32),
+		openContainers: make(map[byte]byte),
+		hiddenHud:      make(map[int32]struct{}),
+		bossBars:       make(map[int64]packet.BossEvent),
+	}
+}
+
+// Observe records the values of the latest packet passed that may later be needed to fill the fields of
+// another packet. It should be called with every packet in the latest version sent over the connection.
+func (s *State) Observe(pk packet.Packet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch pk := pk.(type) {
+	case *packet.StartGame:
+		s.started = true
+		s.entityUniqueID, s.entityRuntimeID = pk.EntityUniqueID, pk.EntityRuntimeID
+		s.movementAuthority = byte(pk.PlayerMovementSettings.MovementType)
+		authority, ok := s.pendingMovementAuthority.Value()
+		if ok {
+			s.movementAuthority = authority
+			s.pendingMovementAuthority = protocol.Optional[byte]{}
+		}
+		s.movementAuthorityOverridden = ok
+		s.riding = false
+		clear(s.openContainers)
+		clear(s.containerSizes)
+		clear(s.bodyArmour)
+		clear(s.hiddenHud)
+		clear(s.bossBars)
+	case *packet.SetActorLink:
+		if pk.EntityLink.RiderEntityUniqueID == s.entityUniqueID {
+			s.riding = pk.EntityLink.Type != protocol.EntityLinkRemove
+		}
+	case *packet.MobArmourEquipment:
+		if pk.Body.Stack.NetworkID != 0 && pk.Chestplate.Stack.NetworkID == 0 {
+			s.bodyArmour[pk.EntityRuntimeID] = struct{}{}
+		} else {
+			delete(s.bodyArmour, pk.EntityRuntimeID)
+		}
+	case *packet.Emote:
+		if pk.EmoteLength != 0 {
+			s.emoteLengths[pk.EmoteID] = pk.EmoteLength
+		}
+	case *packet.ChangeDimension:
+		s.loadingScreen, s.loadingScreenID = loadingScreenPending, pk.LoadingScreenID
+	case *packet.SetMovementAuthority:
+		// Older clients only take their movement authority from StartGame, so the mode can only be changed
+		// before the client has joined.
+		if !s.started {
+			s.pendingMovementAuthority = protocol.Option(pk.MovementType)
+		}
+	case *packet.PlayerAuthInput:
+		s.pitch, s.yaw = pk.Pitch, pk.Yaw
+		s.position, s.inputTick = pk.Position, pk.Tick
+	case *packet.SetHud:
+		for _, element := range pk.Elements {
+			switch pk.Visibility {
+			case packet.HudVisibilityHide:
+				s.hiddenHud[element] = struct{}{}
+			case packet.HudVisibilityReset:
+				delete(s.hiddenHud, element)
+			}
+		}
+	case *packet.BossEvent:
+		s.observeBossEvent(pk)
+	case *packet.EditorNetwork:
+		s.routeToManager = pk.RouteToManager
+	case *packet.ContainerOpen:
+		s.openContainers[pk.WindowID] = pk.ContainerType
+	case *packet.ContainerClose:
+		delete(s.openContainers, pk.WindowID)
+	case *packet.InventoryContent:
+		if id, ok := pk.Container.DynamicContainerID.Value(); ok {
+			s.containerSizes[id] = uint32(len(pk.Content))
+		}
+	case *packet.ContainerRegistryCleanup:
+		for _, container := range pk.RemovedContainers {
+			if id, ok := container.DynamicContainerID.Value(); ok {
+				delete(s.containerSizes, id)
+			}
+		}
+	case *packet.Disconnect:
+		s.disconnected = true
+	}
+}
+
+// observeBossEvent keeps the boss bar shown by the BossEvent passed up to date, so that it can be shown again
+// exactly as it was.
+func (s *State) observeBossEvent(pk *packet.BossEvent) {
+	switch pk.EventType {
+	case packet.BossEventShow:
+		s.bossBars[pk.BossEntityUniqueID] = *pk
+		return
+	case packet.BossEventHide:
+		delete(s.bossBars, pk.BossEntityUniqueID)
+		return
+	}
+	bar, ok := s.bossBars[pk.BossEntityUniqueID]
+	if !ok {
+		return
+	}
+	switch pk.EventType {
+	case packet.BossEventHealthPercentage:
+		bar.HealthPercentage = pk.HealthPercentage
+	case packet.BossEventTitle:
+		bar.BossBarTitle = pk.BossBarTitle
+	case packet.BossEventAppearanceProperties:
+		bar.ScreenDarkening, bar.Colour, bar.Overlay = pk.ScreenDarkening, pk.Colour, pk.Overlay
+	case packet.BossEventTexture:
+		bar.Colour, bar.Overlay = pk.Colour, pk.Overlay
+	}
+	s.bossBars[pk.BossEntityUniqueID] = bar
+}
+
+// EmoteLength returns the length in ticks of the emote with the ID passed, as last sent by the server. If the
+// server has not yet sent the emote, a default length is returned.
+func (s *State) EmoteLength(emoteID string) uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if length, ok := s.emoteLengths[emoteID]; ok {
+		return length
+	}
+	return defaultEmoteLength
+}
+
+// ContainerType returns the type of the container opened in the window passed, and false if no container is
+// currently open in that window.
+func (s *State) ContainerType(windowID byte) (byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	containerType, ok := s.openContainers[windowID]
+	return containerType, ok
+}
+
+// ContainerSize returns the amount of slots of the dynamic container with the ID passed, as last sent in an
+// InventoryContent packet. Zero is returned for containers of which no content was sent yet.
+func (s *State) ContainerSize(container protocol.FullContainerName) uint32 {
+	id, ok := container.DynamicContainerID.Value()
+	if !ok {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.containerSizes[id]
+}
+
+// RouteToManager returns the RouteToManager value of the last EditorNetwork packet sent by the server.
+func (s *State) RouteToManager() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.routeToManager
+}
+
+// Disconnected checks if a Disconnect packet was sent over the connection. No packets should be sent after it.
+func (s *State) Disconnected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.disconnected
+}
+
+// PredictionType returns the prediction type of movement corrections for the player, which depends on
+// whether the player is currently riding an entity.
+func (s *State) PredictionType() byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.riding {
+		return packet.PredictionTypeVehicle
+	}
+	return packet.PredictionTypePlayer
+}
+
+// MovementAuthority returns the movement authority mode that the client is running in, as set in StartGame.
+// Clients older than 1.21.40 cannot change their mode after joining, so it may differ from the mode the
+// server set later using SetMovementAuthority.
+func (s *State) MovementAuthority() byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.movementAuthority
+}
+
+// MovementAuthorityOverride returns the movement authority mode set using SetMovementAuthority before the last
+// StartGame, which overrides the mode of that StartGame. If no such mode was set, false is returned.
+func (s *State) MovementAuthorityOverride() (byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.movementAuthority, s.movementAuthorityOverridden
+}
+
+// BodyArmourInChestplate checks if the entity with the runtime ID passed wears body armour, such as wolf or horse
+// armour, and no chestplate. Versions without a body armour slot show the body armour of such entities in
+// their chestplate slot instead.
+func (s *State) BodyArmourInChestplate(entityRuntimeID uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.bodyArmour[entityRuntimeID]
+	return ok
+}
+
+// EntityRuntimeID returns the runtime ID of the player itself, as sent in StartGame.
+func (s *State) EntityRuntimeID() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entityRuntimeID
+}
+
+// Rotation returns the pitch and yaw of the player, as last sent by the client in PlayerAuthInput.
+func (s *State) Rotation() (pitch, yaw float32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pitch, s.yaw
+}
+
+// LastInput returns the position of the player and the tick at which it was at that position, as last sent by
+// the client in PlayerAuthInput. The tick is 0 if the client has not yet sent PlayerAuthInput.
+func (s *State) LastInput() (position mgl32.Vec3, tick uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.position, s.inputTick
+}
+
+// HudHidden checks if the HUD element passed was hidden by the last SetHud packet that changed it.
+func (s *State) HudHidden(element int32) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.hiddenHud[element]
+	return ok
+}
+
+// BossBars returns the BossEvent packets that show the boss bars currently shown to the client, as they
+// currently look.
+func (s *State) BossBars() []packet.BossEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bars := make([]packet.BossEvent, 0, len(s.bossBars))
+	for _, bar := range s.bossBars {
+		bars = append(bars, bar)
+	}
+	return bars
+}
+
+// SynthesizeLoadingScreen adds the ServerBoundLoadingScreen packets that a client of a version without the
+// packet would have sent around the latest packets passed, which were sent by the client. The loading screen
+// starts with the first PlayerAuthInput sent after the server changed the dimension of the client, and ends
+// once the client reports that the dimension change is done.
+func (s *State) SynthesizeLoadingScreen(pks []packet.Packet) []packet.Packet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loadingScreen == loadingScreenNone {
+		return pks
+	}
+
+	result := make([]packet.Packet, 0, len(pks)+2)
+	for _, pk := range pks {
+		_, input := pk.(*packet.PlayerAuthInput)
+		done := dimensionChangeDone(pk)
+		if s.loadingScreen == loadingScreenPending && (input || done) {
+			result = append(result, &packet.ServerBoundLoadingScreen{Type: packet.LoadingScreenTypeStart, LoadingScreenID: s.loadingScreenID})
+			s.loadingScreen = loadingScreenShown
+		}
+		result = append(result, pk)
+		if s.loadingScreen == loadingScreenShown && done {
+			result = append(result, &packet.ServerBoundLoadingScreen{Type: packet.LoadingScreenTypeEnd, LoadingScreenID: s.loadingScreenID})
+			s.loadingScreen = loadingScreenNone
+		}
+	}
+	return result
+}
+
+// dimensionChangeDone checks if the packet passed reports that the client finished changing dimension, either
+// through a PlayerAction packet or a block action of PlayerAuthInput.
+func dimensionChangeDone(pk packet.Packet) bool {
+	switch pk := pk.(type) {
+	case *packet.PlayerAction:
+		return pk.ActionType == protocol.PlayerActionDimensionChangeDone
+	case *packet.PlayerAuthInput:
+		for _, action := range pk.BlockActions {
+			if action.Action == protocol.PlayerActionDimensionChangeDone {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,314 @@
+package session
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// armour returns a MobArmourEquipment packet of the entity passed, wearing a chestplate and body armour if set.
+func armour(entityRuntimeID uint64, chestplate, body bool) *packet.MobArmourEquipment {
+	pk := &packet.MobArmourEquipment{EntityRuntimeID: entityRuntimeID}
+	if chestplate {
+		pk.Chestplate.Stack.NetworkID = 1
+	}
+	if body {
+		pk.Body.Stack.NetworkID = 2
+	}
+	return pk
+}
+
+// startGame returns a StartGame packet for the player with the runtime ID 1, joining in the movement authority
+// mode passed.
+func startGame(authority int32) *packet.StartGame {
+	pk := &packet.StartGame{EntityUniqueID: 1, EntityRuntimeID: 1}
+	pk.PlayerMovementSettings.MovementType = authority
+	return pk
+}
+
+func TestStateObserve(t *testing.T) {
+	tests := []struct {
+		name  string
+		pks   []packet.Packet
+		check func(t *testing.T, s *State)
+	}{
+		{
+			name: "body armour without chestplate",
+			pks:  []packet.Packet{armour(1, false, true), armour(2, true, true), armour(3, false, false)},
+			check: func(t *testing.T, s *State) {
+				for entity, want := range map[uint64]bool{1: true, 2: false, 3: false} {
+					if got := s.BodyArmourInChestplate(entity); got != want {
+						t.Errorf("entity %v has body armour in chestplate: %v, expected %v", entity, got, want)
+					}
+				}
+			},
+		},
+		{
+			name: "body armour removed",
+			pks:  []packet.Packet{armour(1, false, true), armour(1, false, false)},
+			check: func(t *testing.T, s *State) {
+				if s.BodyArmourInChestplate(1) {
+					t.Errorf("body armour kept after it was removed")
+				}
+			},
+		},
+		{
+			name: "emote lengths",
+			pks:  []packet.Packet{&packet.Emote{EmoteID: "wave", EmoteLength: 40}, &packet.Emote{EmoteID: "wave"}},
+			check: func(t *testing.T, s *State) {
+				if length := s.EmoteLength("wave"); length != 40 {
+					t.Errorf("wave has length %v, expected 40", length)
+				}
+				if length := s.EmoteLength("dance"); length != defaultEmoteLength {
+					t.Errorf("unknown emote has length %v, expected %v", length, defaultEmoteLength)
+				}
+			},
+		},
+		{
+			name: "containers",
+			pks: []packet.Packet{
+				&packet.ContainerOpen{WindowID: 1, ContainerType: protocol.ContainerTypeFurnace},
+				&packet.ContainerOpen{WindowID: 2, ContainerType: protocol.ContainerTypeHopper},
+				&packet.ContainerClose{WindowID: 2},
+			},
+			check: func(t *testing.T, s *State) {
+				if containerType, ok := s.ContainerType(1); !ok || containerType != protocol.ContainerTypeFurnace {
+					t.Errorf("window 1 holds container %v (%v), expected furnace", containerType, ok)
+				}
+				if _, ok := s.ContainerType(2); ok {
+					t.Errorf("closed window 2 still holds a container")
+				}
+			},
+		},
+		{
+			name: "dynamic container sizes",
+			pks: []packet.Packet{
+				&packet.InventoryContent{Container: protocol.FullContainerName{DynamicContainerID: protocol.Option(uint32(4))}, Content: make([]protocol.ItemInstance, 27)},
+				&packet.InventoryContent{Container: protocol.FullContainerName{DynamicContainerID: protocol.Option(uint32(5))}, Content: make([]protocol.ItemInstance, 9)},
+				&packet.ContainerRegistryCleanup{RemovedContainers: []protocol.FullContainerName{{DynamicContainerID: protocol.Option(uint32(5))}}},
+			},
+			check: func(t *testing.T, s *State) {
+				for id, want := range map[uint32]uint32{4: 27, 5: 0} {
+					if size := s.ContainerSize(protocol.FullContainerName{DynamicContainerID: protocol.Option(id)}); size != want {
+						t.Errorf("container %v has size %v, expected %v", id, size, want)
+					}
+				}
+			},
+		},
+		{
+			name: "riding",
+			pks:  []packet.Packet{startGame(0), &packet.SetActorLink{EntityLink: protocol.EntityLink{RiderEntityUniqueID: 1, Type: protocol.EntityLinkRider}}},
+			check: func(t *testing.T, s *State) {
+				if s.PredictionType() != packet.PredictionTypeVehicle {
+					t.Errorf("riding player predicted as player")
+				}
+			},
+		},
+		{
+			name: "riding other entity",
+			pks:  []packet.Packet{startGame(0), &packet.SetActorLink{EntityLink: protocol.EntityLink{RiderEntityUniqueID: 2, Type: protocol.EntityLinkRider}}},
+			check: func(t *testing.T, s *State) {
+				if s.PredictionType() != packet.PredictionTypePlayer {
+					t.Errorf("player predicted as vehicle when another entity rides")
+				}
+			},
+		},
+		{
+			name: "last input",
+			pks:  []packet.Packet{&packet.PlayerAuthInput{Pitch: 10, Yaw: 20, Position: mgl32.Vec3{1, 2, 3}, Tick: 7}},
+			check: func(t *testing.T, s *State) {
+				if pitch, yaw := s.Rotation(); pitch != 10 || yaw != 20 {
+					t.Errorf("rotation %v, %v, expected 10, 20", pitch, yaw)
+				}
+				if position, tick := s.LastInput(); position != (mgl32.Vec3{1, 2, 3}) || tick != 7 {
+					t.Errorf("last input at %v on tick %v, expected (1, 2, 3) on tick 7", position, tick)
+				}
+			},
+		},
+		{
+			name: "HUD hidden and reset",
+			pks: []packet.Packet{
+				&packet.SetHud{Elements: []int32{packet.HudElementHealth, packet.HudElementHunger}, Visibility: packet.HudVisibilityHide},
+				&packet.SetHud{Elements: []int32{packet.HudElementHunger}, Visibility: packet.HudVisibilityReset},
+			},
+			check: func(t *testing.T, s *State) {
+				for element, want := range map[int32]bool{packet.HudElementHealth: true, packet.HudElementHunger: false, packet.HudElementArmour: false} {
+					if got := s.HudHidden(element); got != want {
+						t.Errorf("HUD element %v hidden: %v, expected %v", element, got, want)
+					}
+				}
+			},
+		},
+		{
+			name: "boss bar updated and hidden",
+			pks: []packet.Packet{
+				&packet.BossEvent{BossEntityUniqueID: 1, EventType: packet.BossEventShow, BossBarTitle: "first", HealthPercentage: 1},
+				&packet.BossEvent{BossEntityUniqueID: 2, EventType: packet.BossEventShow, BossBarTitle: "second"},
+				&packet.BossEvent{BossEntityUniqueID: 1, EventType: packet.BossEventHealthPercentage, HealthPercentage: 0.5},
+				&packet.BossEvent{BossEntityUniqueID: 2, EventType: packet.BossEventHide},
+				&packet.BossEvent{BossEntityUniqueID: 3, EventType: packet.BossEventTitle, BossBarTitle: "unknown"},
+			},
+			check: func(t *testing.T, s *State) {
+				bars := s.BossBars()
+				if len(bars) != 1 {
+					t.Fatalf("%v boss bars shown, expected 1", len(bars))
+				}
+				if bars[0].BossBarTitle != "first" || bars[0].HealthPercentage != 0.5 {
+					t.Errorf("boss bar %q at %v health, expected \"first\" at 0.5", bars[0].BossBarTitle, bars[0].HealthPercentage)
+				}
+			},
+		},
+		{
+			name: "StartGame clears state of previous world",
+			pks: []packet.Packet{
+				armour(1, false, true),
+				&packet.ContainerOpen{WindowID: 1},
+				&packet.SetHud{Elements: []int32{packet.HudElementHealth}, Visibility: packet.HudVisibilityHide},
+				&packet.BossEvent{BossEntityUniqueID: 1, EventType: packet.BossEventShow},
+				startGame(0),
+			},
+			check: func(t *testing.T, s *State) {
+				if s.BodyArmourInChestplate(1) || s.HudHidden(packet.HudElementHealth) || len(s.BossBars()) != 0 {
+					t.Errorf("entity or HUD state of previous world kept")
+				}
+				if _, ok := s.ContainerType(1); ok {
+					t.Errorf("container of previous world kept")
+				}
+				if s.EntityRuntimeID() != 1 {
+					t.Errorf("entity runtime ID %v, expected 1", s.EntityRuntimeID())
+				}
+			},
+		},
+		{
+			name: "movement authority set before StartGame",
+			pks:  []packet.Packet{&packet.SetMovementAuthority{MovementType: 2}, startGame(0), &packet.SetMovementAuthority{MovementType: 1}},
+			check: func(t *testing.T, s *State) {
+				if authority, ok := s.MovementAuthorityOverride(); !ok || authority != 2 {
+					t.Errorf("movement authority override %v (%v), expected 2", authority, ok)
+				}
+				if s.MovementAuthority() != 2 {
+					t.Errorf("movement authority %v, expected 2", s.MovementAuthority())
+				}
+			},
+		},
+		{
+			name: "movement authority of StartGame",
+			pks:  []packet.Packet{startGame(1)},
+			check: func(t *testing.T, s *State) {
+				if _, ok := s.MovementAuthorityOverride(); ok {
+					t.Errorf("movement authority overridden without SetMovementAuthority")
+				}
+				if s.MovementAuthority() != 1 {
+					t.Errorf("movement authority %v, expected 1", s.MovementAuthority())
+				}
+			},
+		},
+		{
+			name: "editor routing and disconnect",
+			pks:  []packet.Packet{&packet.EditorNetwork{RouteToManager: true}, &packet.Disconnect{}},
+			check: func(t *testing.T, s *State) {
+				if !s.RouteToManager() {
+					t.Errorf("editor packets not routed to the manager")
+				}
+				if !s.Disconnected() {
+					t.Errorf("connection not disconnected after Disconnect")
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewState()
+			for _, pk := range test.pks {
+				s.Observe(pk)
+			}
+			test.check(t, s)
+		})
+	}
+}
+
+// packetNames returns the names of the types of the packets passed, with the type of ServerBoundLoadingScreen
+// packets replaced by start or end.
+func packetNames(pks []packet.Packet) []string {
+	names := make([]string, len(pks))
+	for i, pk := range pks {
+		switch pk := pk.(type) {
+		case *packet.ServerBoundLoadingScreen:
+			names[i] = "end"
+			if pk.Type == packet.LoadingScreenTypeStart {
+				names[i] = "start"
+			}
+		case *packet.PlayerAuthInput:
+			names[i] = "input"
+			if dimensionChangeDone(pk) {
+				names[i] = "input done"
+			}
+		case *packet.PlayerAction:
+			names[i] = "action done"
+		}
+	}
+	return names
+}
+
+func TestSynthesizeLoadingScreen(t *testing.T) {
+	var (
+		input      = &packet.PlayerAuthInput{}
+		inputDone  = &packet.PlayerAuthInput{BlockActions: []protocol.PlayerBlockAction{{Action: protocol.PlayerActionDimensionChangeDone}}}
+		actionDone = &packet.PlayerAction{ActionType: protocol.PlayerActionDimensionChangeDone}
+	)
+	tests := []struct {
+		name string
+		// changeDimension specifies if the server changed the dimension of the client before the batches were sent.
+		changeDimension bool
+		batches         [][]packet.Packet
+		want            [][]string
+	}{
+		{
+			name:    "no dimension change",
+			batches: [][]packet.Packet{{input, actionDone}},
+			want:    [][]string{{"input", "action done"}},
+		},
+		{
+			name:            "done by PlayerAction in later batch",
+			changeDimension: true,
+			batches:         [][]packet.Packet{{input}, {input}, {actionDone, input}},
+			want:            [][]string{{"start", "input"}, {"input"}, {"action done", "end", "input"}},
+		},
+		{
+			name:            "done by PlayerAuthInput",
+			changeDimension: true,
+			batches:         [][]packet.Packet{{input, inputDone, input}},
+			want:            [][]string{{"start", "input", "input done", "end", "input"}},
+		},
+		{
+			name:            "done before first input",
+			changeDimension: true,
+			batches:         [][]packet.Packet{{actionDone}, {input}},
+			want:            [][]string{{"start", "action done", "end"}, {"input"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewState()
+			if test.changeDimension {
+				s.Observe(&packet.ChangeDimension{LoadingScreenID: protocol.Option(uint32(3))})
+			}
+			for i, batch := range test.batches {
+				pks := s.SynthesizeLoadingScreen(batch)
+				if names := packetNames(pks); !slices.Equal(names, test.want[i]) {
+					t.Fatalf("batch %v became %v, expected %v", i, names, test.want[i])
+				}
+				for _, pk := range pks {
+					if screen, ok := pk.(*packet.ServerBoundLoadingScreen); ok {
+						if id, _ := screen.LoadingScreenID.Value(); id != 3 {
+							t.Errorf("loading screen has ID %v, expected 3", id)
+						}
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package session
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+)
+
+// Store holds a value of type T for every connection it is queried with. Values are created lazily the first
+// time a connection is seen and are removed automatically once the context of that connection is cancelled.
+type Store[T any] struct {
+	mu     sync.Mutex
+	values map[*minecraft.Conn]*T
+	new    func() *T
+}
+
+// NewStore returns a new Store that uses the function passed to create the value for a new connection.
+func NewStore[T any](new func() *T) *Store[T] {
+	return &Store[T]{values: make(map[*minecraft.Conn]*T), new: new}
+}
+
+// Get returns the value held for the connection passed, creating it if it did not yet exist. A nil connection
+// is valid and shares a single value, which is never removed. The value of a connection without a context,
+// such as one that was not created by a listener or dialer, is kept until it is deleted using Delete.
+func (s *Store[T]) Get(conn *minecraft.Conn) *T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.values[conn]; ok {
+		return v
+	}
+	v := s.new()
+	s.values[conn] = v
+	if conn == nil {
+		return v
+	}
+	if ctx := conn.Context(); ctx != nil {
+		context.AfterFunc(ctx, func() {
+			s.Delete(conn)
+		})
+	}
+	return v
+}
+
+// Delete removes the value held for the connection passed, if any.
+func (s *Store[T]) Delete(conn *minecraft.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, conn)
+}
@@ -0,0 +1,23 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+)
+
+func TestStoreConnWithoutContext(t *testing.T) {
+	s := NewStore(NewState)
+	conn := new(minecraft.Conn)
+	state := s.Get(conn)
+	if s.Get(conn) != state {
+		t.Fatalf("connection got a new value on the second call")
+	}
+	if s.Get(new(minecraft.Conn)) == state {
+		t.Fatalf("another connection shares the value of the connection")
+	}
+	s.Delete(conn)
+	if s.Get(conn) == state {
+		t.Fatalf("value of connection kept after deleting it")
+	}
+}
@@ -0,0 +1,286 @@
+// Package version implements the parts of the protocols of legacy versions that are the same for every version:
+// their settings, their per-connection state and the order in which packets are converted. Every protocol
+// embeds a Base and only provides its packets and the conversions of those packets.
+package version
+
+import (
+	"fmt"
+
+	"github.com/oomph-ac/new-mv/compat"
+	"github.com/oomph-ac/new-mv/internal/convert"
+	"github.com/oomph-ac/new-mv/internal/hud"
+	"github.com/oomph-ac/new-mv/internal/input"
+	"github.com/oomph-ac/new-mv/internal/session"
+	"github.com/oomph-ac/new-mv/metrics"
+	"github.com/oomph-ac/new-mv/trace"
+	"github.com/oomph-ac/new-mv/translator"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// Config holds the parts of a protocol that differ between versions.
+type Config struct {
+	// ID is the protocol version of the version.
+	ID int32
+	// ServerPackets and ClientPackets are the pools of the packets sent by servers and clients of the version.
+	ServerPackets, ClientPackets packet.Pool
+	// Upgrade converts packets of the version to the latest version. It is the ProtoUpgrade function of the
+	// protocol.
+	Upgrade func(pks []packet.Packet, state *session.State) []packet.Packet
+	// Downgrade converts packets of the latest version to the version. It is the ProtoDowngrade function of the
+	// protocol.
+	Downgrade func(pks []packet.Packet, state *session.State) []packet.Packet
+	// InputFlags is the layout of the PlayerAuthInput InputData of the version.
+	InputFlags input.FlagLayout
+	// SynthesizeLoadingScreen specifies if clients of the version do not send ServerBoundLoadingScreen, so that
+	// it must be synthesized from the packets they send while changing dimension.
+	SynthesizeLoadingScreen bool
+	// EmulateHud specifies if clients of the version have no SetHud packet, so that it must be emulated with
+	// packets they do understand.
+	EmulateHud bool
+	// ItemTranslator and BlockTranslator translate the items and blocks of the version.
+	ItemTranslator  *translator.DefaultItemTranslator
+	BlockTranslator *translator.DefaultBlockTranslator
+}
+
+// Base implements the methods shared by the protocols of all versions. P is the protocol embedding it, which its
+// setters return so that they may be chained.
+type Base[P any] struct {
+	conf Config
+	wrap func(*Base[P]) P
+	self P
+
+	itemTranslator  *translator.DefaultItemTranslator
+	blockTranslator *translator.DefaultBlockTranslator
+	states          *session.Store[session.State]
+	tracer          *trace.Tracer
+	metrics         *metrics.Reporter
+	unsupported     *compat.Handler
+	dialer          bool
+	errorHandler    func(conn *minecraft.Conn, pk packet.Packet, err error)
+}
+
+// New returns the protocol returned by wrap for a new Base of the version configured by conf.
+func New[P any](conf Config, wrap func(*Base[P]) P) P {
+	b := &Base[P]{
+		conf:            conf,
+		wrap:            wrap,
+		itemTranslator:  conf.ItemTranslator,
+		blockTranslator: conf.BlockTranslator,
+		states:          session.NewStore(session.NewState),
+		unsupported:     compat.NewHandler(compat.Drop, conf.ServerPackets, nil),
+	}
+	b.blockTranslator.SetErrorHandler(b.reportError)
+	return b.init()
+}
+
+// init wraps b in the protocol embedding it and returns that protocol.
+func (b *Base[P]) init() P {
+	b.self = b.wrap(b)
+	return b.self
+}
+
+// WithTracer sets the tracer that the conversions of the protocol are logged to. A nil tracer disables tracing.
+func (b *Base[P]) WithTracer(tracer *trace.Tracer) P {
+	b.tracer = tracer
+	b.itemTranslator.SetTracer(tracer)
+	b.blockTranslator.SetTracer(tracer)
+	return b.self
+}
+
+// WithMetrics sets the metrics that measurements of the conversions of the protocol are reported to. Nil
+// metrics disable reporting.
+func (b *Base[P]) WithMetrics(m metrics.Metrics) P {
+	b.metrics = metrics.NewReporter(m, b.conf.ID)
+	b.itemTranslator.SetMetrics(b.metrics)
+	b.blockTranslator.SetMetrics(b.metrics)
+	return b.self
+}
+
+// WithDialer sets the protocol up for use with a minecraft.Dialer connecting to a server of this version. The
+// packets of the server are then converted for a client of the latest version, and those of the client for
+// the server, instead of the other way around.
+func (b *Base[P]) WithDialer() P {
+	b.dialer = true
+	b.unsupported = b.unsupported.WithPool(b.conf.ClientPackets)
+	return b.self
+}
+
+// WithErrorHandler sets the function called when the conversion of a packet panics, in which case the packet is
+// dropped, or when blocks in it cannot be translated. The connection is kept open either way. The error is also
+// reported to the tracer and metrics of the protocol.
+func (b *Base[P]) WithErrorHandler(h func(conn *minecraft.Conn, pk packet.Packet, err error)) P {
+	b.errorHandler = h
+	return b.self
+}
+
+// WithUnsupportedPolicy sets the policy applied to packets sent by the server that the version has no packet
+// for. Such packets are dropped by default.
+func (b *Base[P]) WithUnsupportedPolicy(policy compat.Policy) P {
+	b.unsupported = b.unsupported.WithPolicy(policy)
+	return b.self
+}
+
+// WithSubstitute sets the substitute of the packet with the ID passed, which is used for the packet if the
+// unsupported packet policy of the protocol is compat.Substitute.
+func (b *Base[P]) WithSubstitute(id uint32, substitute compat.SubstituteFunc) P {
+	b.unsupported = b.unsupported.WithSubstitute(id, substitute)
+	return b.self
+}
+
+// Fork returns a copy of the protocol for the connections to a single upstream server. The fork shares the
+// vanilla item and block mappings of the protocol, but custom items registered to it, and items and block
+// states defined by its server, are never seen by the protocol or by other forks. Custom items registered to
+// the protocol before forking are inherited.
+func (b *Base[P]) Fork() P {
+	fork := new(Base[P])
+	*fork = *b
+	fork.blockTranslator = b.blockTranslator.Fork()
+	fork.blockTranslator.SetErrorHandler(fork.reportError)
+	fork.itemTranslator = b.itemTranslator.Fork(fork.blockTranslator)
+	fork.states = session.NewStore(session.NewState)
+	return fork.init()
+}
+
+// Reset forgets everything known about the connection passed, such as the items and block states its server
+// defined. It is called when a Transfer packet is sent over the connection, and should be called by proxies
+// moving the connection to another server without sending one.
+func (b *Base[P]) Reset(conn *minecraft.Conn) {
+	b.states.Delete(conn)
+	b.itemTranslator.Reset(conn)
+	b.blockTranslator.Reset(conn)
+}
+
+// resetFor resets what is known about the connection passed if the packet passed moves it to another server.
+// The state of the connection is kept on StartGame, as it resets itself while keeping the values sent before
+// StartGame.
+func (b *Base[P]) resetFor(pk packet.Packet, conn *minecraft.Conn) {
+	switch pk.ID() {
+	case packet.IDStartGame:
+		b.itemTranslator.Reset(conn)
+		b.blockTranslator.Reset(conn)
+	case packet.IDTransfer:
+		b.Reset(conn)
+	}
+}
+
+// MovementAuthority returns the movement authority mode that the client on the connection passed is running
+// in. The client cannot change its mode after joining, so it may differ from the mode last set by the server.
+func (b *Base[P]) MovementAuthority(conn *minecraft.Conn) byte {
+	return b.states.Get(conn).MovementAuthority()
+}
+
+// ConvertToLatest converts a packet of the version, sent over the connection passed, to the latest version.
+func (b *Base[P]) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) (pks []packet.Packet) {
+	defer b.recoverConversion(pk, conn, &pks)
+	conversion := b.tracer.Start(conn, b.conf.ID, trace.Upgrade, pk)
+	b.resetFor(pk, conn)
+	state := b.states.Get(conn)
+	pks = b.itemTranslator.UpgradeItemPackets(
+		b.blockTranslator.UpgradeBlockPackets(convert.Packets(b.conf.Upgrade([]packet.Packet{pk}, state), convert.LatestPool(!b.dialer), b.lost(conn)), conn),
+		conn,
+	)
+	if b.conf.SynthesizeLoadingScreen {
+		// Clients of the version do not send ServerBoundLoadingScreen, so it is synthesized from the packets
+		// they send while changing dimension.
+		pks = state.SynthesizeLoadingScreen(pks)
+	}
+	for _, pk := range pks {
+		state.Observe(pk)
+	}
+	conversion.End(pks)
+	return pks
+}
+
+// ConvertFromLatest converts a packet of the latest version, about to be sent over the connection passed, to the
+// version.
+func (b *Base[P]) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) (pks []packet.Packet) {
+	defer b.recoverConversion(pk, conn, &pks)
+	conversion := b.tracer.Start(conn, b.conf.ID, trace.Downgrade, pk)
+	b.resetFor(pk, conn)
+	state := b.states.Get(conn)
+	if state.Disconnected() {
+		conversion.End(nil)
+		return nil
+	}
+	state.Observe(pk)
+	pks = b.blockTranslator.DowngradeBlockPackets(b.itemTranslator.DowngradeItemPackets([]packet.Packet{pk}, conn), conn)
+	if b.conf.EmulateHud {
+		// Clients of the version have no SetHud packet, so it is emulated with packets they do understand.
+		pks = hud.Emulate(pks, state, conn, b.tracer)
+	}
+	pks = b.conf.Downgrade(pks, state)
+	pks, disconnect := b.unsupported.Apply(pks, conn, b.tracer, b.unsupportedMetrics())
+	if disconnect != nil {
+		// The connection cannot be closed while the packet is converted, as it is locked for the packet to be
+		// written. The client closes it instead once it receives the Disconnect packet, and nothing is sent to
+		// it after.
+		state.Observe(disconnect)
+		pks = append(pks, b.conf.Downgrade([]packet.Packet{disconnect}, state)...)
+	}
+	if b.dialer {
+		// The packets of the latest client that Downgrade has no conversion for are converted by name.
+		pks = convert.Packets(b.downgradeInput(pks), b.conf.ClientPackets, b.lost(conn))
+	}
+	conversion.End(pks)
+	return pks
+}
+
+// downgradeInput returns the packets passed with the InputData of PlayerAuthInput packets of the latest client
+// converted to the layout of the version, so that their other fields may be converted by name.
+func (b *Base[P]) downgradeInput(pks []packet.Packet) []packet.Packet {
+	for i, pk := range pks {
+		if pk, ok := pk.(*packet.PlayerAuthInput); ok {
+			// The packet is copied, as it is owned by the caller writing it.
+			downgraded := *pk
+			downgraded.InputData = b.conf.InputFlags.Downgrade(pk.InputData)
+			pks[i] = &downgraded
+		}
+	}
+	return pks
+}
+
+// recoverConversion recovers from a panic during the conversion of the packet passed, so that a single packet
+// that cannot be converted does not crash the process. The packet is dropped, and the error reported.
+func (b *Base[P]) recoverConversion(pk packet.Packet, conn *minecraft.Conn, pks *[]packet.Packet) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	*pks = nil
+	b.reportError(conn, pk, fmt.Errorf("convert %T: %v", pk, r))
+}
+
+// reportError reports an error converting the packet passed to the tracer, the metrics and the error handler of
+// the protocol.
+func (b *Base[P]) reportError(conn *minecraft.Conn, pk packet.Packet, err error) {
+	b.tracer.Error(conn, "convert packet", err)
+	b.metrics.Add(metrics.ConversionErrors, 1)
+	if b.errorHandler != nil {
+		b.errorHandler(conn, pk, err)
+	}
+}
+
+// lost returns the function reporting the fields and packets that could not be converted by name over the
+// connection passed to the tracer and metrics of the protocol. Each of them should be given an explicit
+// conversion.
+func (b *Base[P]) lost(conn *minecraft.Conn) func(pk packet.Packet, field string) {
+	return func(pk packet.Packet, field string) {
+		b.metrics.Add(metrics.ConversionFallbacks, 1)
+		if field == "" {
+			b.tracer.Fallback(conn, "dropped packet without equivalent in target version", "packet", compat.Name(pk))
+			return
+		}
+		b.tracer.Fallback(conn, "dropped field not converted by name", "packet", compat.Name(pk), "field", field)
+	}
+}
+
+// unsupportedMetrics returns the reporter that the unsupported packets are counted by. Packets are only counted
+// as unsupported when they are sent to clients of this version, and not when sent to a server of this version
+// by a dialer.
+func (b *Base[P]) unsupportedMetrics() *metrics.Reporter {
+	if b.dialer {
+		return nil
+	}
+	return b.metrics
+}
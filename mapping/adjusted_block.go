@@ -0,0 +1,111 @@
+package mapping
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/df-mc/worldupgrader/blockupgrader"
+	"github.com/oomph-ac/new-mv/internal"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/segmentio/fasthash/fnv1"
+)
+
+// adjustedBlockMapping is a Block mapping holding custom states on top of a base mapping. Like the client, it
+// orders all states by the hash of their name, which shifts the runtime IDs of the base states that come after
+// a custom state. Only the custom states are stored, so that the base mapping can be shared and an adjusted
+// mapping is cheap to create for every connection. An adjustedBlockMapping is never changed once created.
+type adjustedBlockMapping struct {
+	base *DefaultBlockMapping
+
+	// states holds the custom states, ordered by their runtime ID.
+	states []blockupgrader.BlockState
+	// runtimeIDs holds the runtime IDs of the custom states, in the same order as states.
+	runtimeIDs []uint32
+	// offsets holds for every custom state the amount of base states ordered before it, in the same order as
+	// states.
+	offsets []uint32
+	// stateRuntimeIDs holds a map for looking up the runtime ID of a custom state by the hash it produces.
+	stateRuntimeIDs map[internal.StateHash]uint32
+}
+
+// newAdjustedBlockMapping returns an adjustedBlockMapping holding the custom states passed on top of the base
+// mapping. The base states are expected to be ordered by the hash of their name, as they are in the game.
+func newAdjustedBlockMapping(base *DefaultBlockMapping, states []blockupgrader.BlockState) (*adjustedBlockMapping, error) {
+	sort.SliceStable(states, func(i, j int) bool {
+		return states[i].Name != states[j].Name && fnv1.HashString64(states[i].Name) < fnv1.HashString64(states[j].Name)
+	})
+
+	m := &adjustedBlockMapping{
+		base:            base,
+		states:          states,
+		runtimeIDs:      make([]uint32, len(states)),
+		offsets:         make([]uint32, len(states)),
+		stateRuntimeIDs: make(map[internal.StateHash]uint32, len(states)),
+	}
+	for i, state := range states {
+		hash := fnv1.HashString64(state.Name)
+		offset := sort.Search(len(base.states), func(i int) bool {
+			return fnv1.HashString64(base.states[i].Name) > hash
+		})
+
+		rid := uint32(offset + i)
+		m.offsets[i] = uint32(offset)
+		m.runtimeIDs[i] = rid
+		stateHash, err := internal.HashState(blockupgrader.Upgrade(state))
+		if err != nil {
+			return nil, fmt.Errorf("custom block state: %w", err)
+		}
+		m.stateRuntimeIDs[stateHash] = rid
+	}
+	return m, nil
+}
+
+func (m *adjustedBlockMapping) StateToRuntimeID(state blockupgrader.BlockState) (uint32, bool) {
+	hash, err := internal.HashState(blockupgrader.Upgrade(state))
+	if err != nil {
+		return 0, false
+	}
+	if rid, ok := m.stateRuntimeIDs[hash]; ok {
+		return rid, true
+	}
+	rid, ok := m.base.StateToRuntimeID(state)
+	if !ok {
+		return 0, false
+	}
+	return m.shift(rid), true
+}
+
+func (m *adjustedBlockMapping) RuntimeIDToState(runtimeID uint32) (blockupgrader.BlockState, bool) {
+	i := sort.Search(len(m.runtimeIDs), func(i int) bool {
+		return m.runtimeIDs[i] >= runtimeID
+	})
+	if i < len(m.runtimeIDs) && m.runtimeIDs[i] == runtimeID {
+		return m.states[i], true
+	}
+	return m.base.RuntimeIDToState(runtimeID - uint32(i))
+}
+
+func (m *adjustedBlockMapping) DowngradeBlockActorData(actorData map[string]any) {
+	m.base.DowngradeBlockActorData(actorData)
+}
+
+func (m *adjustedBlockMapping) UpgradeBlockActorData(actorData map[string]any) {
+	m.base.UpgradeBlockActorData(actorData)
+}
+
+// Adjust returns a mapping holding the custom states passed on top of the base mapping. The custom states of
+// m are not carried over, as every StartGame packet holds the full list of custom states.
+func (m *adjustedBlockMapping) Adjust(entries []protocol.BlockEntry) (Block, error) {
+	return m.base.Adjust(entries)
+}
+
+func (m *adjustedBlockMapping) Air() uint32 {
+	return m.shift(m.base.Air())
+}
+
+// shift returns the runtime ID that the base state with the runtime ID passed has in the adjusted mapping.
+func (m *adjustedBlockMapping) shift(runtimeID uint32) uint32 {
+	return runtimeID + uint32(sort.Search(len(m.offsets), func(i int) bool {
+		return m.offsets[i] > runtimeID
+	}))
+}
@@ -2,13 +2,12 @@ package mapping
 
 import (
 	"bytes"
-	"sort"
+	"fmt"
 
 	"github.com/df-mc/worldupgrader/blockupgrader"
 	"github.com/oomph-ac/new-mv/internal"
 	"github.com/sandertv/gophertunnel/minecraft/nbt"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
-	"github.com/segmentio/fasthash/fnv1"
 )
 
 type Block interface {
@@ -20,8 +19,10 @@ type Block interface {
 	DowngradeBlockActorData(map[string]any)
 	// UpgradeBlockActorData upgrades the input sub chunk to the latest block actor.
 	UpgradeBlockActorData(map[string]any)
-	// Adjust adjusts the latest mappings to account for custom states.
-	Adjust([]protocol.BlockEntry)
+	// Adjust returns a mapping holding the states of the mapping along with the custom states passed. The
+	// mapping itself is never changed, so that it may be shared between connections. An error is returned if
+	// the properties of a custom state are malformed.
+	Adjust([]protocol.BlockEntry) (Block, error)
 	Air() uint32
 }
 
@@ -38,7 +39,7 @@ type DefaultBlockMapping struct {
 	airRID uint32
 }
 
-func NewBlockMapping(raw []byte) *DefaultBlockMapping {
+func NewBlockMapping(raw []byte) (*DefaultBlockMapping, error) {
 	dec := nbt.NewDecoder(bytes.NewBuffer(raw))
 
 	var states []blockupgrader.BlockState
@@ -58,11 +59,15 @@ func NewBlockMapping(raw []byte) *DefaultBlockMapping {
 			airRID = &rid
 		}
 
-		stateRuntimeIDs[internal.HashState(blockupgrader.Upgrade(s))] = rid
+		hash, err := internal.HashState(blockupgrader.Upgrade(s))
+		if err != nil {
+			return nil, fmt.Errorf("block state %v: %w", rid, err)
+		}
+		stateRuntimeIDs[hash] = rid
 		runtimeIDToState[rid] = s
 	}
 	if airRID == nil {
-		panic("couldn't find air")
+		return nil, fmt.Errorf("couldn't find air")
 	}
 
 	return &DefaultBlockMapping{
@@ -70,7 +75,7 @@ func NewBlockMapping(raw []byte) *DefaultBlockMapping {
 		stateRuntimeIDs:  stateRuntimeIDs,
 		runtimeIDToState: runtimeIDToState,
 		airRID:           *airRID,
-	}
+	}, nil
 }
 
 func (m *DefaultBlockMapping) WithBlockActorRemapper(downgrader, upgrader func(map[string]any) map[string]any) *DefaultBlockMapping {
@@ -80,7 +85,11 @@ func (m *DefaultBlockMapping) WithBlockActorRemapper(downgrader, upgrader func(m
 }
 
 func (m *DefaultBlockMapping) StateToRuntimeID(state blockupgrader.BlockState) (uint32, bool) {
-	rid, ok := m.stateRuntimeIDs[internal.HashState(blockupgrader.Upgrade(state))]
+	hash, err := internal.HashState(blockupgrader.Upgrade(state))
+	if err != nil {
+		return 0, false
+	}
+	rid, ok := m.stateRuntimeIDs[hash]
 	return rid, ok
 }
 
@@ -101,34 +110,29 @@ func (m *DefaultBlockMapping) UpgradeBlockActorData(actorData map[string]any) {
 	}
 }
 
-func (m *DefaultBlockMapping) Adjust(entries []protocol.BlockEntry) {
+func (m *DefaultBlockMapping) Adjust(entries []protocol.BlockEntry) (Block, error) {
 	if len(entries) == 0 {
-		return
+		return m, nil
 	}
 
-	customStates := convert(entries)
+	states, err := convert(entries)
+	if err != nil {
+		return nil, err
+	}
 	var newStates []blockupgrader.BlockState
-	for _, state := range customStates {
+	for _, state := range states {
 		if _, ok := m.StateToRuntimeID(state); !ok {
 			newStates = append(newStates, state)
 		}
 	}
 	if len(newStates) == 0 {
-		return
+		return m, nil
 	}
-
-	adjustedStates := append(m.states, customStates...)
-	sort.SliceStable(adjustedStates, func(i, j int) bool {
-		stateOne, stateTwo := adjustedStates[i], adjustedStates[j]
-		return stateOne.Name != stateTwo.Name && fnv1.HashString64(stateOne.Name) < fnv1.HashString64(stateTwo.Name)
-	})
-
-	m.stateRuntimeIDs = make(map[internal.StateHash]uint32, len(adjustedStates))
-	m.runtimeIDToState = make(map[uint32]blockupgrader.BlockState, len(adjustedStates))
-	for rid, state := range adjustedStates {
-		m.stateRuntimeIDs[internal.HashState(blockupgrader.Upgrade(state))] = uint32(rid)
-		m.runtimeIDToState[uint32(rid)] = state
+	adjusted, err := newAdjustedBlockMapping(m, newStates)
+	if err != nil {
+		return nil, err
 	}
+	return adjusted, nil
 }
 
 func (m *DefaultBlockMapping) Air() uint32 {
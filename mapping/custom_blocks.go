@@ -1,18 +1,23 @@
 package mapping
 
 import (
+	"fmt"
+
 	"github.com/df-mc/worldupgrader/blockupgrader"
 	"github.com/oomph-ac/new-mv/internal"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
 	"golang.org/x/exp/maps"
 )
 
-func convert(entries []protocol.BlockEntry) (states []blockupgrader.BlockState) {
+func convert(entries []protocol.BlockEntry) (states []blockupgrader.BlockState, err error) {
 	for _, entry := range entries {
 		propertiesMap := map[string][]any{}
 		if props := jsonCheck[[]any](entry.Properties, "properties"); props != nil {
 			for _, prop := range *props {
-				prop := prop.(map[string]any)
+				prop, ok := prop.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("custom block %v: invalid property %v", entry.Name, prop)
+				}
 				name := jsonCheck[string](prop, "name")
 				enum := jsonCheck[[]any](prop, "enum")
 				if enum == nil {
@@ -25,7 +30,7 @@ func convert(entries []protocol.BlockEntry) (states []blockupgrader.BlockState)
 					}
 				}
 				if name == nil || enum == nil {
-					panic("could not find field `name` and `enum`")
+					return nil, fmt.Errorf("custom block %v: could not find field `name` and `enum`", entry.Name)
 				}
 				propertiesMap[*name] = *enum
 			}
@@ -46,7 +51,7 @@ func convert(entries []protocol.BlockEntry) (states []blockupgrader.BlockState)
 			states = append(states, blockState)
 		}
 	}
-	return
+	return states, nil
 }
 
 func generateCombinationsRecursively[K comparable, V any](all map[K][]V, iterator *internal.Iterator[K], current map[K]V, output *[]map[K]V) {
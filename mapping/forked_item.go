@@ -0,0 +1,112 @@
+package mapping
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// forkedItemMapping is an Item mapping that shares the items of a base mapping, but holds the items registered
+// to it using RegisterEntry itself. The base mapping is never changed by it, so that items registered to one
+// fork are not visible to the base mapping or to other forks. Items registered to the base mapping after the
+// fork was created are not visible to the fork either, as their runtime IDs may be taken by items of the fork.
+type forkedItemMapping struct {
+	base *DefaultItemMapping
+	// baseSize is the amount of items the base mapping held when the fork was created. Items of the base mapping
+	// with a runtime ID of baseSize or higher are ignored.
+	baseSize int32
+
+	mu sync.Mutex
+	// itemRuntimeIDsToNames holds a map to translate the runtime IDs of the items registered to the fork to string
+	// IDs.
+	itemRuntimeIDsToNames map[int32]string
+	// itemNamesToRuntimeIDs holds a map to translate the string IDs of the items registered to the fork to runtime
+	// IDs.
+	itemNamesToRuntimeIDs map[string]int32
+}
+
+// newForkedItemMapping returns a forkedItemMapping without any items registered on top of the items the base
+// mapping currently holds.
+func newForkedItemMapping(base *DefaultItemMapping) *forkedItemMapping {
+	return &forkedItemMapping{
+		base:                  base,
+		baseSize:              int32(base.size()),
+		itemRuntimeIDsToNames: make(map[int32]string),
+		itemNamesToRuntimeIDs: make(map[string]int32),
+	}
+}
+
+func (m *forkedItemMapping) ItemRuntimeIDToName(runtimeID int32) (string, bool) {
+	m.mu.Lock()
+	name, ok := m.itemRuntimeIDsToNames[runtimeID]
+	m.mu.Unlock()
+	if ok {
+		return name, true
+	}
+	if runtimeID >= m.baseSize {
+		return "", false
+	}
+	return m.base.ItemRuntimeIDToName(runtimeID)
+}
+
+func (m *forkedItemMapping) ItemNameToRuntimeID(name string) (int32, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.itemNameToRuntimeID(name)
+}
+
+// itemNameToRuntimeID looks up the runtime ID of the item with the name passed. m.mu must be held.
+func (m *forkedItemMapping) itemNameToRuntimeID(name string) (int32, bool) {
+	if rid, ok := m.itemNamesToRuntimeIDs[name]; ok {
+		return rid, true
+	}
+	if rid, ok := m.base.ItemNameToRuntimeID(name); ok && rid < m.baseSize {
+		return rid, true
+	}
+	return 0, false
+}
+
+// RegisterEntry registers the item in the fork, following the runtime IDs of the items of the base mapping.
+func (m *forkedItemMapping) RegisterEntry(name string) int32 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rid, ok := m.itemNameToRuntimeID(name); ok {
+		return rid
+	}
+	nextRID := m.baseSize + int32(len(m.itemRuntimeIDsToNames))
+	m.itemNamesToRuntimeIDs[name] = nextRID
+	m.itemRuntimeIDsToNames[nextRID] = name
+	return nextRID
+}
+
+func (m *forkedItemMapping) Entries() []protocol.ItemEntry {
+	return m.base.Entries()
+}
+
+func (m *forkedItemMapping) Overlay(entries []protocol.ItemEntry) Item {
+	if len(entries) == 0 {
+		return m
+	}
+	return newOverlayItemMapping(m, entries)
+}
+
+// Fork returns a new fork of the base mapping holding the items registered to m so far.
+func (m *forkedItemMapping) Fork() Item {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &forkedItemMapping{
+		base:                  m.base,
+		baseSize:              m.baseSize,
+		itemRuntimeIDsToNames: maps.Clone(m.itemRuntimeIDsToNames),
+		itemNamesToRuntimeIDs: maps.Clone(m.itemNamesToRuntimeIDs),
+	}
+}
+
+func (m *forkedItemMapping) Air() int32 {
+	return m.base.Air()
+}
+
+func (m *forkedItemMapping) ItemVersion() uint16 {
+	return m.base.ItemVersion()
+}
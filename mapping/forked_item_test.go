@@ -0,0 +1,92 @@
+package mapping
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+// newTestItemMapping returns the item mapping of 1.21.30.
+func newTestItemMapping(t *testing.T) *DefaultItemMapping {
+	t.Helper()
+	requiredItemList, err := os.ReadFile("../protocols/v729/required_item_list.json")
+	if err != nil {
+		t.Fatalf("read required item list: %v", err)
+	}
+	m, err := NewItemMapping(nil, requiredItemList, 221, false)
+	if err != nil {
+		t.Fatalf("load item mapping: %v", err)
+	}
+	return m
+}
+
+func TestForkedItemMappingInheritsRegisteredItems(t *testing.T) {
+	base := newTestItemMapping(t)
+	parentRID := base.RegisterEntry("test:parent")
+
+	fork := base.Fork()
+	forkRID := fork.RegisterEntry("test:fork")
+	if forkRID == parentRID {
+		t.Fatalf("item of fork got runtime ID %v of item of base", forkRID)
+	}
+
+	forkOfFork := fork.Fork()
+	for name, rid := range map[string]int32{"test:parent": parentRID, "test:fork": forkRID} {
+		if got, ok := forkOfFork.ItemNameToRuntimeID(name); !ok || got != rid {
+			t.Errorf("%v: fork of fork has runtime ID %v (found: %v), expected %v", name, got, ok, rid)
+		}
+	}
+	if _, ok := base.ItemNameToRuntimeID("test:fork"); ok {
+		t.Errorf("item registered to fork visible to base")
+	}
+}
+
+func TestForkedItemMappingIgnoresLaterBaseItems(t *testing.T) {
+	base := newTestItemMapping(t)
+	fork := base.Fork()
+
+	baseRID := base.RegisterEntry("test:base")
+	forkRID := fork.RegisterEntry("test:fork")
+	if name, ok := fork.ItemRuntimeIDToName(forkRID); !ok || name != "test:fork" {
+		t.Fatalf("runtime ID %v of fork resolves to %q (found: %v), expected test:fork", forkRID, name, ok)
+	}
+	if baseRID != forkRID {
+		t.Fatalf("expected the item of the base (%v) and of the fork (%v) to share a runtime ID", baseRID, forkRID)
+	}
+	if _, ok := fork.ItemNameToRuntimeID("test:base"); ok {
+		t.Fatalf("item registered to base after forking visible to fork")
+	}
+}
+
+func TestForkedItemMappingConcurrentRegister(t *testing.T) {
+	fork := newTestItemMapping(t).Fork()
+
+	const names, goroutines = 32, 8
+	rids := make([][]int32, goroutines)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			rids[g] = make([]int32, names)
+			for i := 0; i < names; i++ {
+				rids[g][i] = fork.RegisterEntry(fmt.Sprintf("test:item_%d", i))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	seen := make(map[int32]int)
+	for i := 0; i < names; i++ {
+		for g := 1; g < goroutines; g++ {
+			if rids[g][i] != rids[0][i] {
+				t.Fatalf("test:item_%d registered under runtime IDs %v and %v", i, rids[0][i], rids[g][i])
+			}
+		}
+		if other, ok := seen[rids[0][i]]; ok {
+			t.Fatalf("test:item_%d and test:item_%d share runtime ID %v", i, other, rids[0][i])
+		}
+		seen[rids[0][i]] = i
+	}
+}
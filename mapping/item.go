@@ -2,8 +2,13 @@ package mapping
 
 import (
 	"encoding/json"
-	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"fmt"
+	"sort"
 	"sync"
+
+	"github.com/oomph-ac/new-mv/internal/item"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
 )
 
 type Item interface {
@@ -11,7 +16,19 @@ type Item interface {
 	ItemRuntimeIDToName(int32) (string, bool)
 	// ItemNameToRuntimeID converts a string ID to an item runtime ID.
 	ItemNameToRuntimeID(string) (int32, bool)
+	// RegisterEntry registers an item with the string ID passed for every session and returns its runtime ID.
+	// It is meant for items registered before the mapping is used, such as custom item substitutes.
 	RegisterEntry(string) int32
+	// Entries returns the item entries the mapping was loaded with, ordered by runtime ID. Entries added using
+	// RegisterEntry are not included.
+	Entries() []protocol.ItemEntry
+	// Overlay returns a mapping holding the items of the mapping along with the entries passed, which keep their
+	// runtime IDs. The mapping itself is never changed, so that items defined by one session are not visible
+	// to others.
+	Overlay([]protocol.ItemEntry) Item
+	// Fork returns a mapping sharing the items of the mapping, to which items may be registered using
+	// RegisterEntry without them becoming visible to the mapping itself or to other forks.
+	Fork() Item
 	Air() int32
 	ItemVersion() uint16
 }
@@ -22,27 +39,50 @@ type DefaultItemMapping struct {
 	itemRuntimeIDsToNames map[int32]string
 	// itemNamesToRuntimeIDs holds a map to translate item string IDs to runtime IDs.
 	itemNamesToRuntimeIDs map[string]int32
-	airRID                int32
-	itemVersion           uint16
+	// entries holds the item entries the mapping was loaded with.
+	entries     []protocol.ItemEntry
+	airRID      int32
+	itemVersion uint16
 }
 
-func NewItemMapping(itemRuntimeIDData []byte, requiredItemList []byte, itemVersion uint16, direct bool) *DefaultItemMapping {
+// NewItemMapping returns the item mapping of a version. If direct is true, it is loaded from the NBT item
+// runtime ID data, and otherwise from the JSON required item list.
+func NewItemMapping(itemRuntimeIDData []byte, requiredItemList []byte, itemVersion uint16, direct bool) (*DefaultItemMapping, error) {
+	if err := item.Load(); err != nil {
+		return nil, fmt.Errorf("load item upgrade data: %w", err)
+	}
 	itemRuntimeIDsToNames := make(map[int32]string)
 	itemNamesToRuntimeIDs := make(map[string]int32)
+	var entries []protocol.ItemEntry
 	var airRID *int32
 
 	if direct {
-		var items map[string]int32
+		// The NBT holds either the runtime ID of every item, or a compound with the runtime ID and whether the
+		// item is component based, like the required item list.
+		var items map[string]any
 		if err := nbt.Unmarshal(itemRuntimeIDData, &items); err != nil {
-			panic(err)
+			return nil, fmt.Errorf("decode item runtime IDs: %w", err)
 		}
-		for name, rid := range items {
+		for name, data := range items {
+			entry := protocol.ItemEntry{Name: name}
+			switch data := data.(type) {
+			case int32:
+				entry.RuntimeID = int16(data)
+			case map[string]any:
+				runtimeID, _ := data["runtime_id"].(int32)
+				componentBased, _ := data["component_based"].(uint8)
+				entry.RuntimeID, entry.ComponentBased = int16(runtimeID), componentBased != 0
+			default:
+				return nil, fmt.Errorf("invalid item runtime ID data for %v: %T", name, data)
+			}
+			rid := int32(entry.RuntimeID)
 			if name == "minecraft:air" {
 				airRID = &rid
 			}
 
 			itemNamesToRuntimeIDs[name] = rid
 			itemRuntimeIDsToNames[rid] = name
+			entries = append(entries, entry)
 		}
 	} else {
 		var m map[string]struct {
@@ -50,7 +90,7 @@ func NewItemMapping(itemRuntimeIDData []byte, requiredItemList []byte, itemVersi
 			ComponentBased bool  `json:"component_based"`
 		}
 		if err := json.Unmarshal(requiredItemList, &m); err != nil {
-			panic(err)
+			return nil, fmt.Errorf("decode required item list: %w", err)
 		}
 		for name, data := range m {
 			rid := int32(data.RuntimeID)
@@ -60,14 +100,18 @@ func NewItemMapping(itemRuntimeIDData []byte, requiredItemList []byte, itemVersi
 
 			itemNamesToRuntimeIDs[name] = rid
 			itemRuntimeIDsToNames[rid] = name
+			entries = append(entries, protocol.ItemEntry{Name: name, RuntimeID: data.RuntimeID, ComponentBased: data.ComponentBased})
 		}
 	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].RuntimeID < entries[j].RuntimeID
+	})
 
 	if airRID == nil {
-		panic("couldn't find air")
+		return nil, fmt.Errorf("couldn't find air")
 	}
 
-	return &DefaultItemMapping{itemRuntimeIDsToNames: itemRuntimeIDsToNames, itemNamesToRuntimeIDs: itemNamesToRuntimeIDs, itemVersion: itemVersion}
+	return &DefaultItemMapping{itemRuntimeIDsToNames: itemRuntimeIDsToNames, itemNamesToRuntimeIDs: itemNamesToRuntimeIDs, entries: entries, itemVersion: itemVersion}, nil
 }
 
 func (m *DefaultItemMapping) ItemRuntimeIDToName(runtimeID int32) (name string, found bool) {
@@ -96,6 +140,28 @@ func (m *DefaultItemMapping) RegisterEntry(name string) int32 {
 	return nextRID
 }
 
+func (m *DefaultItemMapping) Entries() []protocol.ItemEntry {
+	return append([]protocol.ItemEntry(nil), m.entries...)
+}
+
+func (m *DefaultItemMapping) Overlay(entries []protocol.ItemEntry) Item {
+	if len(entries) == 0 {
+		return m
+	}
+	return newOverlayItemMapping(m, entries)
+}
+
+func (m *DefaultItemMapping) Fork() Item {
+	return newForkedItemMapping(m)
+}
+
+// size returns the amount of items held by the mapping, including those registered using RegisterEntry.
+func (m *DefaultItemMapping) size() int {
+	defer m.mu.Unlock()
+	m.mu.Lock()
+	return len(m.itemRuntimeIDsToNames)
+}
+
 func (m *DefaultItemMapping) Air() int32 {
 	defer m.mu.Unlock()
 	m.mu.Lock()
@@ -0,0 +1,138 @@
+package mapping
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// overlayItemMapping is an Item mapping holding items defined for a single session on top of a base mapping.
+// The base mapping is never changed by it, so that it can be shared between sessions while the runtime IDs of
+// the items in the overlay are scoped to the session that defined them. Items registered to the overlay using
+// RegisterEntry are held by the overlay itself as well.
+type overlayItemMapping struct {
+	base Item
+
+	mu sync.Mutex
+	// itemRuntimeIDsToNames holds a map to translate the runtime IDs of the items in the overlay to string IDs.
+	itemRuntimeIDsToNames map[int32]string
+	// itemNamesToRuntimeIDs holds a map to translate the string IDs of the items in the overlay to runtime IDs.
+	itemNamesToRuntimeIDs map[string]int32
+	// registered holds the names of the items registered to the overlay using RegisterEntry, by runtime ID.
+	registered map[int32]string
+	// nextRID is the lowest runtime ID that may be given to the next item registered using RegisterEntry.
+	nextRID int32
+}
+
+// newOverlayItemMapping returns an overlayItemMapping holding the entries passed on top of the base mapping.
+func newOverlayItemMapping(base Item, entries []protocol.ItemEntry) *overlayItemMapping {
+	m := &overlayItemMapping{
+		base:                  base,
+		itemRuntimeIDsToNames: make(map[int32]string, len(entries)),
+		itemNamesToRuntimeIDs: make(map[string]int32, len(entries)),
+		registered:            make(map[int32]string),
+	}
+	for _, entry := range entries {
+		m.itemRuntimeIDsToNames[int32(entry.RuntimeID)] = entry.Name
+		m.itemNamesToRuntimeIDs[entry.Name] = int32(entry.RuntimeID)
+		m.nextRID = max(m.nextRID, int32(entry.RuntimeID)+1)
+	}
+	return m
+}
+
+func (m *overlayItemMapping) ItemRuntimeIDToName(runtimeID int32) (string, bool) {
+	m.mu.Lock()
+	name, ok := m.itemRuntimeIDsToNames[runtimeID]
+	m.mu.Unlock()
+	if ok {
+		return name, true
+	}
+	return m.base.ItemRuntimeIDToName(runtimeID)
+}
+
+func (m *overlayItemMapping) ItemNameToRuntimeID(name string) (int32, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.itemNameToRuntimeID(name)
+}
+
+// itemNameToRuntimeID looks up the runtime ID of the item with the name passed. m.mu must be held.
+func (m *overlayItemMapping) itemNameToRuntimeID(name string) (int32, bool) {
+	if rid, ok := m.itemNamesToRuntimeIDs[name]; ok {
+		return rid, true
+	}
+	return m.base.ItemNameToRuntimeID(name)
+}
+
+// RegisterEntry registers the item in the overlay, under the lowest runtime ID following the items of the
+// overlay that is not taken by an item of the base mapping. The base mapping is never changed.
+func (m *overlayItemMapping) RegisterEntry(name string) int32 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rid, ok := m.itemNameToRuntimeID(name); ok {
+		return rid
+	}
+	rid := m.nextRID
+	for {
+		if _, ok := m.base.ItemRuntimeIDToName(rid); !ok {
+			break
+		}
+		rid++
+	}
+	m.register(rid, name)
+	return rid
+}
+
+// register adds the item with the runtime ID and name passed to the items registered to the overlay. m.mu must
+// be held.
+func (m *overlayItemMapping) register(rid int32, name string) {
+	m.itemNamesToRuntimeIDs[name] = rid
+	m.itemRuntimeIDsToNames[rid] = name
+	m.registered[rid] = name
+	m.nextRID = max(m.nextRID, rid+1)
+}
+
+func (m *overlayItemMapping) Entries() []protocol.ItemEntry {
+	return m.base.Entries()
+}
+
+// Overlay returns a mapping holding the entries passed on top of the base mapping. The entries of m are not
+// carried over, as every StartGame packet holds the full item table, but the items registered to m using
+// RegisterEntry are, unless the entries passed hold an item with the same name or runtime ID.
+func (m *overlayItemMapping) Overlay(entries []protocol.ItemEntry) Item {
+	overlay := newOverlayItemMapping(m.base, entries)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for rid, name := range m.registered {
+		_, nameTaken := overlay.itemNamesToRuntimeIDs[name]
+		_, ridTaken := overlay.itemRuntimeIDsToNames[rid]
+		if !nameTaken && !ridTaken {
+			overlay.register(rid, name)
+		}
+	}
+	return overlay
+}
+
+// Fork returns a fork of m holding the entries of m on top of a fork of the base mapping. Items registered to
+// the fork are not visible to m, and the other way around.
+func (m *overlayItemMapping) Fork() Item {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &overlayItemMapping{
+		base:                  m.base.Fork(),
+		itemRuntimeIDsToNames: maps.Clone(m.itemRuntimeIDsToNames),
+		itemNamesToRuntimeIDs: maps.Clone(m.itemNamesToRuntimeIDs),
+		registered:            maps.Clone(m.registered),
+		nextRID:               m.nextRID,
+	}
+}
+
+func (m *overlayItemMapping) Air() int32 {
+	return m.base.Air()
+}
+
+func (m *overlayItemMapping) ItemVersion() uint16 {
+	return m.base.ItemVersion()
+}
@@ -0,0 +1,89 @@
+package mapping
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+func TestOverlayItemMappingConcurrentSessions(t *testing.T) {
+	base := newTestItemMapping(t)
+	rid := int16(len(base.Entries()) + 100)
+
+	sessions := []struct {
+		item   string
+		custom string
+		m      Item
+	}{
+		{item: "test:ruby", custom: "test:custom_ruby"},
+		{item: "test:sapphire", custom: "test:custom_sapphire"},
+	}
+	var wg sync.WaitGroup
+	for i := range sessions {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := &sessions[i]
+			s.m = base.Fork().Overlay([]protocol.ItemEntry{{Name: s.item, RuntimeID: rid}})
+			for j := 0; j < 16; j++ {
+				s.m.RegisterEntry(fmt.Sprintf("%v_%d", s.custom, j))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, s := range sessions {
+		other := sessions[1-i]
+		if name, ok := s.m.ItemRuntimeIDToName(int32(rid)); !ok || name != s.item {
+			t.Errorf("%v: runtime ID %v resolves to %q (found: %v)", s.item, rid, name, ok)
+		}
+		if _, ok := s.m.ItemNameToRuntimeID(other.item); ok {
+			t.Errorf("%v: item %v of other session visible", s.item, other.item)
+		}
+		customRID, ok := s.m.ItemNameToRuntimeID(s.custom + "_0")
+		if !ok {
+			t.Fatalf("%v: registered item not found", s.item)
+		}
+		if customRID == int32(rid) {
+			t.Errorf("%v: registered item got runtime ID %v of item in overlay", s.item, customRID)
+		}
+		if _, ok := s.m.ItemNameToRuntimeID(other.custom + "_0"); ok {
+			t.Errorf("%v: item registered to other session visible", s.item)
+		}
+	}
+	for _, name := range []string{"test:ruby", "test:custom_ruby_0", "test:sapphire", "test:custom_sapphire_0"} {
+		if _, ok := base.ItemNameToRuntimeID(name); ok {
+			t.Errorf("%v visible to base", name)
+		}
+	}
+}
+
+func TestOverlayItemMappingForkKeepsEntries(t *testing.T) {
+	base := newTestItemMapping(t)
+	overlay := base.Overlay([]protocol.ItemEntry{{Name: "test:ruby", RuntimeID: int16(len(base.Entries()))}})
+	registeredRID := overlay.RegisterEntry("test:registered")
+
+	fork := overlay.Fork()
+	forkRID := fork.RegisterEntry("test:fork")
+	for name, want := range map[string]int32{"test:ruby": int32(len(base.Entries())), "test:registered": registeredRID} {
+		if got, ok := fork.ItemNameToRuntimeID(name); !ok || got != want {
+			t.Errorf("%v: fork has runtime ID %v (found: %v), expected %v", name, got, ok, want)
+		}
+	}
+	if forkRID == registeredRID {
+		t.Errorf("item of fork got runtime ID %v of item of overlay", forkRID)
+	}
+	if _, ok := overlay.ItemNameToRuntimeID("test:fork"); ok {
+		t.Errorf("item registered to fork visible to overlay")
+	}
+
+	next := overlay.Overlay([]protocol.ItemEntry{{Name: "test:sapphire", RuntimeID: int16(len(base.Entries()))}})
+	if got, ok := next.ItemNameToRuntimeID("test:registered"); !ok || got != registeredRID {
+		t.Errorf("registered item has runtime ID %v (found: %v) in next overlay, expected %v", got, ok, registeredRID)
+	}
+	if _, ok := next.ItemNameToRuntimeID("test:ruby"); ok {
+		t.Errorf("entry of previous overlay visible to next overlay")
+	}
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds of the buckets of the histograms of Expvar.
+var DefaultBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// Expvar is a Metrics implementation that publishes its counters and histograms as an expvar.Map. Every value
+// is keyed by the protocol version and name, such as v630.block_fallbacks.
+type Expvar struct {
+	vars *expvar.Map
+
+	mu         sync.Mutex
+	histograms map[string]*histogram
+}
+
+// NewExpvar returns an Expvar publishing its values under the name passed. Like expvar.NewMap, it panics if
+// the name is already in use.
+func NewExpvar(name string) *Expvar {
+	return &Expvar{vars: expvar.NewMap(name), histograms: make(map[string]*histogram)}
+}
+
+// Add adds delta to the counter with the name passed for the protocol version passed, creating it if it does
+// not yet exist.
+func (e *Expvar) Add(protocol int32, name string, delta int64) {
+	e.vars.Add(key(protocol, name), delta)
+}
+
+// Observe records the value passed in the histogram with the name passed for the protocol version passed. The
+// histogram is created with DefaultBuckets the first time a value is observed in it.
+func (e *Expvar) Observe(protocol int32, name string, value float64) {
+	k := key(protocol, name)
+
+	e.mu.Lock()
+	h, ok := e.histograms[k]
+	if !ok {
+		h = newHistogram(DefaultBuckets)
+		e.histograms[k] = h
+		e.vars.Set(k, h)
+	}
+	e.mu.Unlock()
+	h.observe(value)
+}
+
+// key returns the key of the value with the name passed for the protocol version passed.
+func key(protocol int32, name string) string {
+	return fmt.Sprintf("v%d.%s", protocol, name)
+}
+
+// histogram is an expvar.Var counting the values observed in buckets.
+type histogram struct {
+	mu     sync.Mutex
+	bounds []float64
+	// counts holds the amount of values in each bucket, with one more bucket than there are bounds for the
+	// values above the last bound.
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// newHistogram returns an empty histogram with the bucket bounds passed.
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+// observe adds the value passed to the histogram.
+func (h *histogram) observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[sort.SearchFloat64s(h.bounds, value)]++
+	h.count++
+	h.sum += value
+}
+
+// String returns the histogram as JSON, as required by expvar.Var.
+func (h *histogram) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buckets := make(map[string]uint64, len(h.counts))
+	for i, count := range h.counts {
+		bound := "+Inf"
+		if i < len(h.bounds) {
+			bound = fmt.Sprint(h.bounds[i])
+		}
+		buckets[bound] = count
+	}
+	b, _ := json.Marshal(struct {
+		Count   uint64            `json:"count"`
+		Sum     float64           `json:"sum"`
+		Buckets map[string]uint64 `json:"buckets"`
+	}{Count: h.count, Sum: h.sum, Buckets: buckets})
+	return string(b)
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExpvarCounters(t *testing.T) {
+	tests := []struct {
+		name string
+		// report reports measurements to the Expvar passed.
+		report func(e *Expvar)
+		want   map[string]int64
+	}{
+		{
+			name: "counters added",
+			report: func(e *Expvar) {
+				r := NewReporter(e, 630)
+				r.Add(BlockFallbacks, 2)
+				r.Add(BlockFallbacks, 3)
+				r.Add(ItemFallbacks, 1)
+			},
+			want: map[string]int64{"v630.block_fallbacks": 5, "v630.item_fallbacks": 1},
+		},
+		{
+			name: "counters kept per protocol",
+			report: func(e *Expvar) {
+				NewReporter(e, 630).Add(UnsupportedPacketsDropped, 1)
+				NewReporter(e, 712).Add(UnsupportedPacketsDropped, 4)
+				NewReporter(e, 712).Add(UnsupportedPacketsSubstituted, 2)
+			},
+			want: map[string]int64{"v630.unsupported_packets_dropped": 1, "v712.unsupported_packets_dropped": 4, "v712.unsupported_packets_substituted": 2},
+		},
+		{
+			name: "nil reporter",
+			report: func(e *Expvar) {
+				var r *Reporter
+				r.Add(ChunkErrors, 1)
+				r.Observe(ChunkDowngradeSeconds, 1)
+			},
+			want: map[string]int64{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := NewExpvar(t.Name())
+			test.report(e)
+
+			var got map[string]int64
+			if err := json.Unmarshal([]byte(e.vars.String()), &got); err != nil {
+				t.Fatalf("decode counters: %v", err)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("got counters %v, expected %v", got, test.want)
+			}
+			for k, want := range test.want {
+				if got[k] != want {
+					t.Errorf("%v is %v, expected %v", k, got[k], want)
+				}
+			}
+		})
+	}
+}
+
+func TestExpvarHistogram(t *testing.T) {
+	e := NewExpvar(t.Name())
+	r := NewReporter(e, 630)
+	for _, value := range []float64{0.00005, 0.001, 0.002, 10} {
+		r.Observe(ChunkDowngradeSeconds, value)
+	}
+
+	var got struct {
+		Count   uint64            `json:"count"`
+		Sum     float64           `json:"sum"`
+		Buckets map[string]uint64 `json:"buckets"`
+	}
+	if err := json.Unmarshal([]byte(e.vars.Get("v630.chunk_downgrade_seconds").String()), &got); err != nil {
+		t.Fatalf("decode histogram: %v", err)
+	}
+	if got.Count != 4 || got.Sum < 10.003 || got.Sum > 10.0031 {
+		t.Fatalf("histogram holds %v values summing to %v, expected 4 summing to 10.00305", got.Count, got.Sum)
+	}
+	want := map[string]uint64{"0.0001": 1, "0.001": 1, "0.005": 1, "+Inf": 1}
+	for bound, count := range got.Buckets {
+		if count != want[bound] {
+			t.Errorf("bucket %v holds %v values, expected %v", bound, count, want[bound])
+		}
+	}
+	if len(got.Buckets) != len(DefaultBuckets)+1 {
+		t.Errorf("histogram has %v buckets, expected %v", len(got.Buckets), len(DefaultBuckets)+1)
+	}
+}
+
+func TestNewExpvarNameInUse(t *testing.T) {
+	NewExpvar(t.Name())
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), t.Name()) {
+			t.Fatalf("reusing the name %v did not panic", t.Name())
+		}
+	}()
+	NewExpvar(t.Name())
+}
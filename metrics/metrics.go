@@ -0,0 +1,73 @@
+// Package metrics defines the Metrics that the translation of packets between protocol versions is measured
+// with, along with Expvar, a default implementation publishing them through the expvar package.
+package metrics
+
+const (
+	// BlockFallbacks counts the block runtime IDs that had no equivalent in the target version and became air.
+	BlockFallbacks = "block_fallbacks"
+	// ItemFallbacks counts the item types that had no equivalent in the target version and became the
+	// minecraft:info_update placeholder.
+	ItemFallbacks = "item_fallbacks"
+	// ChunkErrors counts the chunks and sub chunks that could not be decoded or encoded and were passed on
+	// without being translated.
+	ChunkErrors = "chunk_errors"
+	// UnsupportedPacketsDropped counts the packets sent to legacy clients that their version has no packet for,
+	// and that were dropped by the unsupported packet policy of the protocol.
+	UnsupportedPacketsDropped = "unsupported_packets_dropped"
+	// UnsupportedPacketsSubstituted counts the packets sent to legacy clients that their version has no packet
+	// for, and that were replaced by their substitute.
+	UnsupportedPacketsSubstituted = "unsupported_packets_substituted"
+	// UnsupportedPacketsDisconnected counts the packets sent to legacy clients that their version has no packet
+	// for, and for which the client was disconnected.
+	UnsupportedPacketsDisconnected = "unsupported_packets_disconnected"
+	// ConversionErrors counts the packets that could not be converted because their conversion panicked, and
+	// were dropped.
+	ConversionErrors = "conversion_errors"
+	// ConversionFallbacks counts the fields that held a value but could not be copied, and the packets that
+	// had no equivalent in the target version, when converting packets without an explicit conversion by
+	// the names of their fields.
+	ConversionFallbacks = "conversion_fallbacks"
+	// ChunkDowngradeSeconds is the histogram of the time taken to downgrade a chunk, in seconds.
+	ChunkDowngradeSeconds = "chunk_downgrade_seconds"
+)
+
+// Metrics receives the measurements of the translation of packets. Implementations must be safe for
+// concurrent use.
+type Metrics interface {
+	// Add adds delta to the counter with the name passed for the protocol version passed.
+	Add(protocol int32, name string, delta int64)
+	// Observe records a value in the histogram with the name passed for the protocol version passed.
+	Observe(protocol int32, name string, value float64)
+}
+
+// Reporter reports the measurements of a single protocol version to Metrics. A nil *Reporter is valid and
+// reports nothing, so that it may be used without checking if metrics are enabled.
+type Reporter struct {
+	m        Metrics
+	protocol int32
+}
+
+// NewReporter returns a Reporter reporting the measurements of the protocol version passed to m. If m is nil,
+// nil is returned.
+func NewReporter(m Metrics, protocol int32) *Reporter {
+	if m == nil {
+		return nil
+	}
+	return &Reporter{m: m, protocol: protocol}
+}
+
+// Add adds delta to the counter with the name passed.
+func (r *Reporter) Add(name string, delta int64) {
+	if r == nil {
+		return
+	}
+	r.m.Add(r.protocol, name, delta)
+}
+
+// Observe records a value in the histogram with the name passed.
+func (r *Reporter) Observe(name string, value float64) {
+	if r == nil {
+		return
+	}
+	r.m.Observe(r.protocol, name, value)
+}
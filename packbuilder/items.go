@@ -15,12 +15,12 @@ import (
 
 // buildItems builds all the item-related files for the resource pack. This includes textures, language
 // entries and item atlas.
-func buildItems(dir string, customItems []world.CustomItem) (count int, lang []string) {
+func buildItems(dir string, customItems []world.CustomItem) (count int, lang []string, err error) {
 	if err := os.Mkdir(filepath.Join(dir, "items"), os.ModePerm); err != nil {
-		panic(err)
+		return 0, nil, err
 	}
 	if err := os.MkdirAll(filepath.Join(dir, "textures/items"), os.ModePerm); err != nil {
-		panic(err)
+		return 0, nil, err
 	}
 
 	textureData := make(map[string]any)
@@ -31,24 +31,26 @@ func buildItems(dir string, customItems []world.CustomItem) (count int, lang []s
 		name := strings.Split(identifier, ":")[1]
 		textureData[name] = map[string]string{"textures": fmt.Sprintf("textures/items/%s.png", name)}
 
-		buildItemTexture(dir, name, item.Texture())
+		if err := buildItemTexture(dir, name, item.Texture()); err != nil {
+			return 0, nil, fmt.Errorf("build texture of %v: %w", identifier, err)
+		}
 
 		count++
 	}
 
-	buildItemAtlas(dir, map[string]any{
+	err = buildItemAtlas(dir, map[string]any{
 		"resource_pack_name": "vanilla",
 		"texture_name":       "atlas.items",
 		"texture_data":       textureData,
 	})
-	return
+	return count, lang, err
 }
 
 // buildItemTexture creates a PNG file for the item from the provided image and name and writes it to the pack.
-func buildItemTexture(dir, name string, img image.Image) {
+func buildItemTexture(dir, name string, img image.Image) error {
 	texture, err := os.Create(filepath.Join(dir, "textures/items", name+".png"))
 	if err != nil {
-		panic(err)
+		return err
 	}
 	if img == nil {
 		im := image.NewAlpha(image.Rect(0, 0, 64, 64))
@@ -62,20 +64,16 @@ func buildItemTexture(dir, name string, img image.Image) {
 
 	if err := png.Encode(texture, img); err != nil {
 		_ = texture.Close()
-		panic(err)
-	}
-	if err := texture.Close(); err != nil {
-		panic(err)
+		return err
 	}
+	return texture.Close()
 }
 
 // buildItemAtlas creates the identifier to texture mapping and writes it to the pack.
-func buildItemAtlas(dir string, atlas map[string]any) {
+func buildItemAtlas(dir string, atlas map[string]any) error {
 	b, err := json.Marshal(atlas)
 	if err != nil {
-		panic(err)
-	}
-	if err := os.WriteFile(filepath.Join(dir, "textures/item_texture.json"), b, 0666); err != nil {
-		panic(err)
+		return err
 	}
+	return os.WriteFile(filepath.Join(dir, "textures/item_texture.json"), b, 0666)
 }
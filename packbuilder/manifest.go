@@ -2,6 +2,7 @@ package packbuilder
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/sandertv/gophertunnel/minecraft/resource"
 	"os"
@@ -12,7 +13,11 @@ import (
 
 // buildManifest creates a JSON manifest file for the client to be able to read the resource pack. It creates
 // basic information and writes it to the pack.
-func buildManifest(dir, version string, headerUUID, moduleUUID uuid.UUID) {
+func buildManifest(dir, version string, headerUUID, moduleUUID uuid.UUID) error {
+	minimumVersion, err := parseVersion(version)
+	if err != nil {
+		return err
+	}
 	m, err := json.Marshal(resource.Manifest{
 		FormatVersion: 2,
 		Header: resource.Header{
@@ -20,7 +25,7 @@ func buildManifest(dir, version string, headerUUID, moduleUUID uuid.UUID) {
 			Description:        "This resource pack contains auto-generated content from dragonfly",
 			UUID:               headerUUID.String(),
 			Version:            [3]int{0, 0, 1},
-			MinimumGameVersion: parseVersion(version),
+			MinimumGameVersion: minimumVersion,
 		},
 		Modules: []resource.Module{
 			{
@@ -32,21 +37,19 @@ func buildManifest(dir, version string, headerUUID, moduleUUID uuid.UUID) {
 		},
 	})
 	if err != nil {
-		panic(err)
-	}
-	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), m, 0666); err != nil {
-		panic(err)
+		return err
 	}
+	return os.WriteFile(filepath.Join(dir, "manifest.json"), m, 0666)
 }
 
 // parseVersion parses the version passed in the format of a.b.c as a [3]int.
-func parseVersion(ver string) [3]int {
+func parseVersion(ver string) ([3]int, error) {
 	frag := strings.Split(ver, ".")
 	if len(frag) != 3 {
-		panic("invalid version number " + ver)
+		return [3]int{}, fmt.Errorf("invalid version number %v", ver)
 	}
 	a, _ := strconv.ParseInt(frag[0], 10, 64)
 	b, _ := strconv.ParseInt(frag[1], 10, 64)
 	c, _ := strconv.ParseInt(frag[2], 10, 64)
-	return [3]int{int(a), int(b), int(c)}
+	return [3]int{int(a), int(b), int(c)}, nil
 }
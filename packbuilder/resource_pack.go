@@ -1,6 +1,7 @@
 package packbuilder
 
 import (
+	"fmt"
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/rogpeppe/go-internal/dirhash"
 	"github.com/sandertv/gophertunnel/minecraft/resource"
@@ -9,32 +10,43 @@ import (
 
 // BuildResourcePack builds a resource pack based on custom features that have been registered to the server.
 // It creates a UUID based on the hash of the directory so the client will only be prompted to download it
-// once it is changed.
-func BuildResourcePack(customItems []world.CustomItem, version string) (*resource.Pack, bool) {
+// once it is changed. If there are no custom features to build a resource pack for, false is returned.
+func BuildResourcePack(customItems []world.CustomItem, version string) (*resource.Pack, bool, error) {
 	dir, err := os.MkdirTemp("", "dragonfly_resource_pack-")
 	if err != nil {
-		panic(err)
+		return nil, false, err
 	}
 	defer os.RemoveAll(dir)
 
 	var assets int
 	var lang []string
 
-	itemCount, itemLang := buildItems(dir, customItems)
+	itemCount, itemLang, err := buildItems(dir, customItems)
+	if err != nil {
+		return nil, false, fmt.Errorf("build items: %w", err)
+	}
 	assets += itemCount
 	lang = append(lang, itemLang...)
 
-	if assets > 0 {
-		buildLanguageFile(dir, lang)
-		hash, err := dirhash.HashDir(dir, "", dirhash.Hash1)
-		if err != nil {
-			panic(err)
-		}
-		var header, module [16]byte
-		copy(header[:], hash)
-		copy(module[:], hash[16:])
-		buildManifest(dir, version, header, module)
-		return resource.MustReadPath(dir), true
+	if assets == 0 {
+		return nil, false, nil
+	}
+	if err := buildLanguageFile(dir, lang); err != nil {
+		return nil, false, fmt.Errorf("build language file: %w", err)
+	}
+	hash, err := dirhash.HashDir(dir, "", dirhash.Hash1)
+	if err != nil {
+		return nil, false, fmt.Errorf("hash resource pack: %w", err)
+	}
+	var header, module [16]byte
+	copy(header[:], hash)
+	copy(module[:], hash[16:])
+	if err := buildManifest(dir, version, header, module); err != nil {
+		return nil, false, fmt.Errorf("build manifest: %w", err)
+	}
+	pack, err := resource.ReadPath(dir)
+	if err != nil {
+		return nil, false, fmt.Errorf("read resource pack: %w", err)
 	}
-	return nil, false
+	return pack, true, nil
 }
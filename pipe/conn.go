@@ -0,0 +1,165 @@
+package pipe
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Addr is the address of a listener or connection of the pipe network.
+type Addr string
+
+// Network ...
+func (Addr) Network() string {
+	return "pipe"
+}
+
+// String ...
+func (a Addr) String() string {
+	return string(a)
+}
+
+// Conn is one end of an in-memory connection. Unlike net.Pipe, it preserves the boundaries of the messages
+// written to it, like a RakNet connection does: every Read or ReadPacket returns exactly one message written
+// by the other end.
+type Conn struct {
+	local, remote Addr
+
+	in  <-chan []byte
+	out chan<- []byte
+
+	closed    chan struct{}
+	closeOnce *sync.Once
+	// remoteClosed is closed once the other end of the connection is closed.
+	remoteClosed <-chan struct{}
+
+	mu                          sync.Mutex
+	readDeadline, writeDeadline time.Time
+}
+
+// newConnPair returns the two ends of a new in-memory connection between the addresses passed.
+func newConnPair(a, b Addr) (*Conn, *Conn) {
+	aToB, bToA := make(chan []byte, 256), make(chan []byte, 256)
+	aClosed, bClosed := make(chan struct{}), make(chan struct{})
+	return &Conn{local: a, remote: b, in: bToA, out: aToB, closed: aClosed, closeOnce: new(sync.Once), remoteClosed: bClosed},
+		&Conn{local: b, remote: a, in: aToB, out: bToA, closed: bClosed, closeOnce: new(sync.Once), remoteClosed: aClosed}
+}
+
+// ReadPacket reads the next message written by the other end of the connection.
+func (c *Conn) ReadPacket() ([]byte, error) {
+	c.mu.Lock()
+	deadline := c.readDeadline
+	c.mu.Unlock()
+
+	timeout, stop := deadlineChan(deadline)
+	defer stop()
+
+	select {
+	case b := <-c.in:
+		return b, nil
+	case <-c.closed:
+		return nil, net.ErrClosed
+	case <-c.remoteClosed:
+		// Messages written before the other end was closed may still be read.
+		select {
+		case b := <-c.in:
+			return b, nil
+		default:
+			return nil, net.ErrClosed
+		}
+	case <-timeout:
+		return nil, os.ErrDeadlineExceeded
+	}
+}
+
+// Read reads the next message written by the other end of the connection into b. If b is too small to hold
+// the message, the rest of it is discarded.
+func (c *Conn) Read(b []byte) (int, error) {
+	msg, err := c.ReadPacket()
+	if err != nil {
+		return 0, err
+	}
+	return copy(b, msg), nil
+}
+
+// Write writes b as a single message to the other end of the connection.
+func (c *Conn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	deadline := c.writeDeadline
+	c.mu.Unlock()
+
+	timeout, stop := deadlineChan(deadline)
+	defer stop()
+
+	msg := append([]byte(nil), b...)
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	case <-c.remoteClosed:
+		return 0, net.ErrClosed
+	default:
+	}
+	select {
+	case c.out <- msg:
+		return len(b), nil
+	case <-c.closed:
+		return 0, net.ErrClosed
+	case <-c.remoteClosed:
+		return 0, net.ErrClosed
+	case <-timeout:
+		return 0, os.ErrDeadlineExceeded
+	}
+}
+
+// Close closes the connection. Reads and writes on both ends fail once it is closed.
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+// LocalAddr ...
+func (c *Conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// RemoteAddr ...
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// SetDeadline ...
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline, c.writeDeadline = t, t
+	return nil
+}
+
+// SetReadDeadline ...
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline = t
+	return nil
+}
+
+// SetWriteDeadline ...
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeDeadline = t
+	return nil
+}
+
+// deadlineChan returns a channel that is closed once the deadline passed is reached, along with a function to
+// release its resources. A zero deadline never expires.
+func deadlineChan(deadline time.Time) (<-chan time.Time, func()) {
+	if deadline.IsZero() {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(time.Until(deadline))
+	return timer.C, func() { timer.Stop() }
+}
@@ -0,0 +1,154 @@
+// Package pipe implements an in-memory minecraft.Network, registered under the name "pipe". It allows a
+// gophertunnel listener and dialer in the same process to be connected without opening any sockets, which
+// makes it possible to run full sessions, from login to chunks, in tests:
+//
+//	server, _ := v686.New(false)
+//	client, _ := v686.New(false)
+//	l, _ := minecraft.ListenConfig{AcceptedProtocols: []minecraft.Protocol{server}}.Listen("pipe", "127.0.0.1:19132")
+//	conn, _ := minecraft.Dialer{Protocol: client.WithDialer()}.Dial("pipe", "127.0.0.1:19132")
+//
+// Listener addresses are only valid within the process, but must be UDP addresses, as gophertunnel requires
+// the address dialed, which the client sends to the server when logging in, to be one.
+package pipe
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+var (
+	// listenersMu guards listeners.
+	listenersMu sync.Mutex
+	// listeners holds all open listeners, keyed by their address.
+	listeners = make(map[string]*Listener)
+	// clientCount is used to give every dialed connection a unique address.
+	clientCount atomic.Int64
+)
+
+// Network is an in-memory minecraft.Network.
+type Network struct{}
+
+// DialContext connects to the listener listening on the address passed.
+func (Network) DialContext(ctx context.Context, address string) (net.Conn, error) {
+	l, err := listener(address)
+	if err != nil {
+		return nil, err
+	}
+	client, server := newConnPair(Addr(fmt.Sprintf("client-%d", clientCount.Add(1))), Addr(address))
+	select {
+	case l.incoming <- server:
+		return client, nil
+	case <-l.closed:
+		return nil, &net.OpError{Op: "dial", Net: "pipe", Addr: Addr(address), Err: net.ErrClosed}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// PingContext returns the pong data of the listener listening on the address passed.
+func (Network) PingContext(_ context.Context, address string) ([]byte, error) {
+	l, err := listener(address)
+	if err != nil {
+		return nil, err
+	}
+	return l.pongData.Load().([]byte), nil
+}
+
+// Listen starts listening on the address passed, which must be a UDP address. An error is returned if another
+// listener is already listening on it.
+func (Network) Listen(address string) (minecraft.NetworkListener, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, &net.OpError{Op: "listen", Net: "pipe", Addr: Addr(address), Err: err}
+	}
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	if _, ok := listeners[address]; ok {
+		return nil, &net.OpError{Op: "listen", Net: "pipe", Addr: Addr(address), Err: fmt.Errorf("address already in use")}
+	}
+	l := &Listener{addr: Addr(address), udpAddr: udpAddr, id: rand.Int64(), incoming: make(chan *Conn), closed: make(chan struct{})}
+	l.pongData.Store([]byte(nil))
+	listeners[address] = l
+	return l, nil
+}
+
+// Compression ...
+func (Network) Compression(net.Conn) packet.Compression {
+	return packet.FlateCompression
+}
+
+// listener returns the listener listening on the address passed.
+func listener(address string) (*Listener, error) {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	l, ok := listeners[address]
+	if !ok {
+		return nil, &net.OpError{Op: "dial", Net: "pipe", Addr: Addr(address), Err: fmt.Errorf("connection refused")}
+	}
+	return l, nil
+}
+
+// Listener is a listener of the pipe network.
+type Listener struct {
+	addr Addr
+	// udpAddr is the address of the listener as a UDP address, which minecraft.Listener requires its listener
+	// to have.
+	udpAddr  *net.UDPAddr
+	id       int64
+	pongData atomic.Value
+
+	incoming  chan *Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Accept waits for the next connection dialed to the listener.
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.incoming:
+		return conn, nil
+	case <-l.closed:
+		return nil, &net.OpError{Op: "accept", Net: "pipe", Addr: l.addr, Err: net.ErrClosed}
+	}
+}
+
+// Addr returns the address of the listener as a *net.UDPAddr, as minecraft.Listener requires.
+func (l *Listener) Addr() net.Addr {
+	return l.udpAddr
+}
+
+// ID ...
+func (l *Listener) ID() int64 {
+	return l.id
+}
+
+// PongData sets the data returned when the listener is pinged.
+func (l *Listener) PongData(data []byte) {
+	l.pongData.Store(append([]byte(nil), data...))
+}
+
+// Close stops the listener and frees its address.
+func (l *Listener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+		listenersMu.Lock()
+		delete(listeners, string(l.addr))
+		listenersMu.Unlock()
+	})
+	return nil
+}
+
+// init registers the pipe network.
+func init() {
+	minecraft.RegisterNetwork("pipe", func(*slog.Logger) minecraft.Network {
+		return Network{}
+	})
+}
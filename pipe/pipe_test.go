@@ -0,0 +1,117 @@
+package pipe_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/df-mc/dragonfly/server/world"
+	"github.com/df-mc/worldupgrader/blockupgrader"
+	"github.com/oomph-ac/new-mv/internal/chunk"
+	_ "github.com/oomph-ac/new-mv/pipe"
+	"github.com/oomph-ac/new-mv/protocols/latest"
+	"github.com/oomph-ac/new-mv/protocols/v686"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// address is the address the listener of the tests listens on.
+const address = "127.0.0.1:19132"
+
+// TestLevelChunkThroughLegacyProtocol runs a session between a listener accepting 1.21.2 and a dialer of 1.21.2,
+// so that a LevelChunk sent by the server is downgraded to 1.21.2 on the wire and upgraded again by the dialer.
+func TestLevelChunkThroughLegacyProtocol(t *testing.T) {
+	server, err := v686.New(false)
+	if err != nil {
+		t.Fatalf("create server protocol: %v", err)
+	}
+	client, err := v686.New(false)
+	if err != nil {
+		t.Fatalf("create client protocol: %v", err)
+	}
+
+	l, err := minecraft.ListenConfig{AcceptedProtocols: []minecraft.Protocol{server}, AuthenticationDisabled: true}.Listen("pipe", address)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer l.Close()
+
+	blocks, err := latest.NewBlockMapping()
+	if err != nil {
+		t.Fatalf("load latest block states: %v", err)
+	}
+	encoding := chunk.NewBlockPaletteEncoding(blocks, latest.BlockVersion)
+	stone, ok := blocks.StateToRuntimeID(blockupgrader.BlockState{Name: "minecraft:stone", Properties: map[string]any{}})
+	if !ok {
+		t.Fatalf("stone missing from latest block states")
+	}
+	c := chunk.New(blocks.Air(), world.Overworld.Range())
+	c.SetBlock(1, 2, 3, 0, stone)
+	payload, err := chunk.NetworkEncode(blocks.Air(), c, false, encoding)
+	if err != nil {
+		t.Fatalf("encode chunk: %v", err)
+	}
+	sent := &packet.LevelChunk{
+		Position:      protocol.ChunkPos{4, 5},
+		SubChunkCount: uint32(len(c.Sub())),
+		RawPayload:    append([]byte(nil), payload...),
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		netConn, err := l.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		conn := netConn.(*minecraft.Conn)
+		defer conn.Close()
+		if err := conn.StartGame(minecraft.GameData{WorldName: "pipe", Dimension: packet.DimensionOverworld}); err != nil {
+			serverErr <- err
+			return
+		}
+		if err := conn.WritePacket(sent); err != nil {
+			serverErr <- err
+			return
+		}
+		serverErr <- conn.Flush()
+		// Keep the connection open until the client is done reading.
+		_, _ = conn.ReadPacket()
+	}()
+
+	conn, err := minecraft.Dialer{Protocol: client.WithDialer()}.Dial("pipe", address)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.DoSpawnTimeout(10 * time.Second); err != nil {
+		t.Fatalf("spawn: %v", err)
+	}
+	if err := <-serverErr; err != nil {
+		t.Fatalf("server: %v", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	for {
+		pk, err := conn.ReadPacket()
+		if err != nil {
+			t.Fatalf("read LevelChunk: %v", err)
+		}
+		received, ok := pk.(*packet.LevelChunk)
+		if !ok {
+			continue
+		}
+		if received.Position != sent.Position {
+			t.Fatalf("received chunk at %v, expected %v", received.Position, sent.Position)
+		}
+		decoded, err := chunk.NetworkDecode(blocks.Air(), bytes.NewBuffer(received.RawPayload), int(received.SubChunkCount), false, world.Overworld.Range(), chunk.NewNetworkPersistentEncoding(blocks, latest.BlockVersion), encoding)
+		if err != nil {
+			t.Fatalf("decode received chunk: %v", err)
+		}
+		if rid := decoded.Block(1, 2, 3, 0); rid != stone {
+			t.Fatalf("received block %v, expected stone (%v)", rid, stone)
+		}
+		return
+	}
+}
@@ -2,8 +2,8 @@ package latest
 
 import (
 	_ "embed"
+	"sync"
 
-	"github.com/oomph-ac/new-mv/internal/chunk"
 	"github.com/oomph-ac/new-mv/mapping"
 )
 
@@ -18,14 +18,15 @@ var (
 	//go:embed block_states.nbt
 	blockStateData []byte
 
-	// blockMapping is the BlockMapping used for translating blocks between versions.
-	blockMapping = mapping.NewBlockMapping(blockStateData)
-	// NetworkPersistentEncoding is the Encoding used for sending a Chunk over network. It uses NBT, unlike NetworkEncoding.
-	NetworkPersistentEncoding = chunk.NewNetworkPersistentEncoding(blockMapping, BlockVersion)
-	// BlockPaletteEncoding is the paletteEncoding used for encoding a palette of block states encoded as NBT.
-	BlockPaletteEncoding = chunk.NewBlockPaletteEncoding(blockMapping, BlockVersion)
+	// blockMapping loads the BlockMapping used for translating blocks between versions the first time it is
+	// called.
+	blockMapping = sync.OnceValues(func() (*mapping.DefaultBlockMapping, error) {
+		return mapping.NewBlockMapping(blockStateData)
+	})
 )
 
-func NewBlockMapping() *mapping.DefaultBlockMapping {
-	return blockMapping
+// NewBlockMapping returns the block mapping of the latest version, or an error if its block states could not
+// be decoded. The mapping is loaded once and shared by every caller.
+func NewBlockMapping() (*mapping.DefaultBlockMapping, error) {
+	return blockMapping()
 }
@@ -15,6 +15,10 @@ var (
 	itemRuntimeIDData []byte
 )
 
-func NewItemMapping(direct bool) mapping.Item {
-	return mapping.NewItemMapping(itemRuntimeIDData, requiredItemList, ItemVersion, direct)
+func NewItemMapping(direct bool) (mapping.Item, error) {
+	m, err := mapping.NewItemMapping(itemRuntimeIDData, requiredItemList, ItemVersion, direct)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
 }
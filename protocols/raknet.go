@@ -4,19 +4,75 @@ import (
 	"context"
 	"log/slog"
 	"net"
+	"sync"
+	"sync/atomic"
 
 	"github.com/sandertv/go-raknet"
 	"github.com/sandertv/gophertunnel/minecraft"
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 )
 
-// MultiRakNet is an implementation of a RakNet v9/10 Network.
+// Config holds the configuration of a MultiRakNet network.
+type Config struct {
+	// Compression is the compression algorithm announced in the NetworkSettings packet and used for the
+	// connections of listeners without a compression of their own. If nil, packet.FlateCompression is used.
+	// Use packet.NopCompression to disable compression altogether, for example on LAN links.
+	Compression packet.Compression
+	// ListenerCompression overrides Compression for the listeners listening on the addresses used as keys.
+	// The addresses must be equal to the ones passed to minecraft.Listen.
+	ListenerCompression map[string]packet.Compression
+	// CompressionThreshold is the compression threshold announced to clients in the NetworkSettings packet: the
+	// minimum size of a batch for it to be compressed by the client. If 0, the threshold of gophertunnel is
+	// left as is.
+	CompressionThreshold uint16
+	// ListenerCompressionThreshold overrides CompressionThreshold for the listeners listening on the addresses
+	// used as keys. The addresses must be equal to the ones passed to minecraft.Listen.
+	ListenerCompressionThreshold map[string]uint16
+}
+
+// MultiRakNet is an implementation of a RakNet Network accepting clients speaking both RakNet v10 and v11.
 type MultiRakNet struct {
-	l *slog.Logger
+	l    *slog.Logger
+	conf Config
+	// listeners holds the compression of every listener with a compression override, keyed by the local
+	// address it is bound to.
+	listeners *sync.Map
 }
 
-// legacyRakNet represents the legacy version of RakNet, necessary for versions higher or equal to v1.16.0.
-const legacyRakNet = 10
+const (
+	// legacyRakNet represents the legacy version of RakNet, necessary for versions higher or equal to v1.16.0.
+	legacyRakNet = 10
+	// currentRakNet represents the version of RakNet used by versions higher or equal to v1.19.30.
+	currentRakNet = 11
+)
+
+// conns holds every open connection accepted by a MultiRakNet listener, keyed by its local and remote address.
+var conns sync.Map
+
+// connKey returns the key of the connection between the addresses passed in conns.
+func connKey(local, remote net.Addr) string {
+	return local.String() + "|" + remote.String()
+}
+
+// ProtocolVersion returns the RakNet protocol version that the client of the connection passed offered. False
+// is returned if the connection was not accepted by a MultiRakNet listener or if its version is unknown.
+func ProtocolVersion(conn *minecraft.Conn) (byte, bool) {
+	c, ok := conns.Load(connKey(conn.LocalAddr(), conn.RemoteAddr()))
+	if !ok {
+		return 0, false
+	}
+	return c.(*Conn).version, true
+}
+
+// MTU returns the MTU size that the client of the connection passed opened its RakNet connection with. False is
+// returned if the connection was not accepted by a MultiRakNet listener or if its MTU size is unknown.
+func MTU(conn *minecraft.Conn) (uint16, bool) {
+	c, ok := conns.Load(connKey(conn.LocalAddr(), conn.RemoteAddr()))
+	if !ok {
+		return 0, false
+	}
+	return c.(*Conn).mtu, true
+}
 
 // DialContext ...
 func (r MultiRakNet) DialContext(ctx context.Context, address string) (net.Conn, error) {
@@ -30,20 +86,130 @@ func (r MultiRakNet) PingContext(ctx context.Context, address string) (response
 
 // Listen ...
 func (r MultiRakNet) Listen(address string) (minecraft.NetworkListener, error) {
-	return raknet.ListenConfig{
-		ErrorLog:         r.l.With("net origin", "raknet"),
-		ProtocolVersions: []byte{legacyRakNet}, // Version 10 is required for legacy versions.
+	h := &handshakes{m: make(map[string]handshake)}
+	l, err := raknet.ListenConfig{
+		ErrorLog:               r.l.With("net origin", "raknet"),
+		ProtocolVersions:       []byte{legacyRakNet, currentRakNet}, // Version 10 is required for legacy versions.
+		UpstreamPacketListener: packetListener{handshakes: h},
 	}.Listen(address)
+	if err != nil {
+		return nil, err
+	}
+	if compression, ok := r.conf.ListenerCompression[address]; ok {
+		r.listeners.Store(l.Addr().String(), compression)
+	}
+	threshold, ok := r.conf.ListenerCompressionThreshold[address]
+	if !ok {
+		threshold = r.conf.CompressionThreshold
+	}
+	return listener{Listener: l, listeners: r.listeners, handshakes: h, threshold: threshold}, nil
 }
 
-// Compression ...
-func (MultiRakNet) Compression(net.Conn) packet.Compression {
+// Compression returns the compression of the listener that accepted the connection passed, or the default
+// compression of the network if the listener has none of its own or the connection was dialed. Clients on
+// the legacy version of RakNet predate the negotiation of compression: they do not expect the compression
+// algorithm to prefix their batches and always use packet.FlateCompression.
+func (r MultiRakNet) Compression(conn net.Conn) packet.Compression {
+	if conn != nil {
+		if c, ok := conn.(*Conn); ok && c.version == legacyRakNet {
+			return packet.FlateCompression
+		}
+		if compression, ok := r.listeners.Load(conn.LocalAddr().String()); ok {
+			return compression.(packet.Compression)
+		}
+	}
+	if r.conf.Compression != nil {
+		return r.conf.Compression
+	}
 	return packet.FlateCompression
 }
 
-// init registers the MultiRakNet network. It overrides the existing minecraft.RakNet network.
-func init() {
+// listener wraps a raknet.Listener to remove its compression override once it is closed and to record the
+// RakNet protocol version and MTU size of the connections it accepts.
+type listener struct {
+	*raknet.Listener
+	listeners  *sync.Map
+	handshakes *handshakes
+	threshold  uint16
+}
+
+// Accept ...
+func (l listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	rc, ok := conn.(*raknet.Conn)
+	if !ok {
+		return conn, nil
+	}
+	c := &Conn{Conn: rc, version: currentRakNet, threshold: l.threshold}
+	if hs, ok := l.handshakes.take(conn.RemoteAddr()); ok {
+		c.version, c.mtu = hs.version, hs.mtu
+	}
+	conns.Store(connKey(c.LocalAddr(), c.RemoteAddr()), c)
+	return c, nil
+}
+
+// Close ...
+func (l listener) Close() error {
+	l.listeners.Delete(l.Addr().String())
+	return l.Listener.Close()
+}
+
+// Conn is a connection accepted by a MultiRakNet listener. It holds the RakNet protocol version and MTU size
+// that its client opened the connection with.
+type Conn struct {
+	*raknet.Conn
+	version byte
+	mtu     uint16
+
+	// threshold is the compression threshold announced to the client, or 0 to leave it as is.
+	threshold uint16
+	// written is true once the first batch, which holds the NetworkSettings packet, was written to the connection.
+	written atomic.Bool
+}
+
+// ProtocolVersion returns the RakNet protocol version that the client of the connection offered.
+func (c *Conn) ProtocolVersion() byte {
+	return c.version
+}
+
+// MTU returns the MTU size that the client of the connection opened it with, or 0 if it is unknown.
+func (c *Conn) MTU() uint16 {
+	return c.mtu
+}
+
+// Write writes the batch passed to the connection. The compression threshold in the NetworkSettings packet is
+// replaced with the one of the listener.
+func (c *Conn) Write(b []byte) (int, error) {
+	if c.threshold != 0 && c.written.CompareAndSwap(false, true) {
+		if settings, ok := withCompressionThreshold(b, c.threshold); ok {
+			if _, err := c.Conn.Write(settings); err != nil {
+				return 0, err
+			}
+			return len(b), nil
+		}
+	}
+	return c.Conn.Write(b)
+}
+
+// Close ...
+func (c *Conn) Close() error {
+	conns.Delete(connKey(c.LocalAddr(), c.RemoteAddr()))
+	return c.Conn.Close()
+}
+
+// Register registers a MultiRakNet network with the configuration passed under the name "raknet", replacing
+// the network registered by default.
+func Register(conf Config) {
+	listeners := new(sync.Map)
 	minecraft.RegisterNetwork("raknet", func(l *slog.Logger) minecraft.Network {
-		return MultiRakNet{l: l}
+		return MultiRakNet{l: l, conf: conf, listeners: listeners}
 	})
 }
+
+// init registers the MultiRakNet network. It overrides the existing minecraft.RakNet network.
+func init() {
+	Register(Config{})
+}
@@ -0,0 +1,126 @@
+package raknet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+const (
+	// idOpenConnectionRequest1 is the ID of the first packet a client sends to open a RakNet connection. It holds
+	// the RakNet protocol version of the client and is padded to the MTU size the client is probing.
+	idOpenConnectionRequest1 = 0x05
+	// udpHeaderSize is the size of the IP and UDP headers of a datagram, which are part of the MTU size but not of
+	// the datagrams read from a net.PacketConn.
+	udpHeaderSize = 20 + 8
+	// handshakeTimeout is the time after which the handshake of a client that did not finish connecting is
+	// forgotten.
+	handshakeTimeout = time.Second * 10
+)
+
+// unconnectedMessageSequence is the magic sequence of bytes that every unconnected RakNet message holds.
+var unconnectedMessageSequence = [16]byte{0x00, 0xff, 0xff, 0x00, 0xfe, 0xfe, 0xfe, 0xfe, 0xfd, 0xfd, 0xfd, 0xfd, 0x12, 0x34, 0x56, 0x78}
+
+// handshake holds what a client offered in the handshake of its RakNet connection.
+type handshake struct {
+	// version is the RakNet protocol version of the client.
+	version byte
+	// mtu is the largest MTU size the client probed with.
+	mtu uint16
+	// at is the time the client last sent a handshake packet.
+	at time.Time
+}
+
+// handshakes records the handshakes of the clients connecting to a single listener, keyed by their address,
+// until the connection is accepted.
+type handshakes struct {
+	mu sync.Mutex
+	m  map[string]handshake
+}
+
+// observe records the handshake packet passed, sent by the address passed, if it is an open connection request.
+func (h *handshakes) observe(b []byte, addr net.Addr) {
+	if len(b) < 1+len(unconnectedMessageSequence)+1 || b[0] != idOpenConnectionRequest1 || !bytes.Equal(b[1:17], unconnectedMessageSequence[:]) {
+		return
+	}
+	now := time.Now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for key, other := range h.m {
+		if now.Sub(other.at) > handshakeTimeout {
+			delete(h.m, key)
+		}
+	}
+	// Clients retry with smaller MTU sizes if the larger ones are not answered, so the largest size that was
+	// probed since the last accepted connection is not necessarily the one in use. The last one is.
+	h.m[addr.String()] = handshake{version: b[17], mtu: uint16(len(b) + udpHeaderSize), at: now}
+}
+
+// take returns and forgets the handshake of the address passed.
+func (h *handshakes) take(addr net.Addr) (handshake, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hs, ok := h.m[addr.String()]
+	delete(h.m, addr.String())
+	return hs, ok
+}
+
+// packetListener is a raknet.UpstreamPacketListener that records the handshakes of the clients connecting.
+type packetListener struct {
+	handshakes *handshakes
+}
+
+// ListenPacket ...
+func (l packetListener) ListenPacket(network, address string) (net.PacketConn, error) {
+	conn, err := net.ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return handshakeConn{PacketConn: conn, handshakes: l.handshakes}, nil
+}
+
+// handshakeConn is a net.PacketConn that records the handshakes of the clients sending datagrams to it.
+type handshakeConn struct {
+	net.PacketConn
+	handshakes *handshakes
+}
+
+// ReadFrom ...
+func (c handshakeConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, addr, err := c.PacketConn.ReadFrom(b)
+	if err == nil {
+		c.handshakes.observe(b[:n], addr)
+	}
+	return n, addr, err
+}
+
+// withCompressionThreshold returns a copy of the batch passed with the compression threshold of the
+// NetworkSettings packet in it replaced by the one passed. False is returned if the batch does not hold a
+// NetworkSettings packet. The packet is the first sent to a client and is sent on its own, before compression
+// and encryption are enabled, so it is found at the start of the uncompressed batch.
+func withCompressionThreshold(b []byte, threshold uint16) ([]byte, bool) {
+	const batchHeader = 0xfe
+	if len(b) < 1 || b[0] != batchHeader {
+		return nil, false
+	}
+	buf := bytes.NewReader(b[1:])
+	if _, err := binary.ReadUvarint(buf); err != nil {
+		return nil, false
+	}
+	header, err := binary.ReadUvarint(buf)
+	if err != nil || header&0x3ff != packet.IDNetworkSettings {
+		return nil, false
+	}
+	offset := len(b) - buf.Len()
+	if len(b) < offset+2 {
+		return nil, false
+	}
+	settings := append([]byte(nil), b...)
+	binary.LittleEndian.PutUint16(settings[offset:], threshold)
+	return settings, true
+}
@@ -0,0 +1,79 @@
+package raknet
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// openConnectionRequest1 returns an open connection request of the RakNet version passed, padded to probe the
+// MTU size passed.
+func openConnectionRequest1(version byte, mtu int) []byte {
+	b := make([]byte, mtu-udpHeaderSize)
+	b[0] = idOpenConnectionRequest1
+	copy(b[1:], unconnectedMessageSequence[:])
+	b[17] = version
+	return b
+}
+
+func TestHandshakes(t *testing.T) {
+	h := &handshakes{m: make(map[string]handshake)}
+	legacy := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+	current := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2}
+
+	h.observe(openConnectionRequest1(legacyRakNet, 1492), legacy)
+	h.observe(openConnectionRequest1(currentRakNet, 1492), current)
+	// The client retries with a smaller MTU size if the first request is not answered.
+	h.observe(openConnectionRequest1(currentRakNet, 1200), current)
+	// Other packets, such as unconnected pings, are ignored.
+	h.observe([]byte{0x01, 0x02}, legacy)
+
+	for _, c := range []struct {
+		addr    net.Addr
+		version byte
+		mtu     uint16
+	}{
+		{addr: legacy, version: legacyRakNet, mtu: 1492},
+		{addr: current, version: currentRakNet, mtu: 1200},
+	} {
+		hs, ok := h.take(c.addr)
+		if !ok {
+			t.Fatalf("%v: no handshake recorded", c.addr)
+		}
+		if hs.version != c.version || hs.mtu != c.mtu {
+			t.Fatalf("%v: recorded version %v and MTU %v, expected %v and %v", c.addr, hs.version, hs.mtu, c.version, c.mtu)
+		}
+		if _, ok := h.take(c.addr); ok {
+			t.Fatalf("%v: handshake not forgotten once taken", c.addr)
+		}
+	}
+}
+
+func TestHandshakesExpire(t *testing.T) {
+	h := &handshakes{m: make(map[string]handshake)}
+	stale := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+	h.m[stale.String()] = handshake{version: currentRakNet, at: time.Now().Add(-handshakeTimeout * 2)}
+
+	h.observe(openConnectionRequest1(currentRakNet, 1400), &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2})
+	if _, ok := h.take(stale); ok {
+		t.Fatalf("handshake of client that never connected was not forgotten")
+	}
+}
+
+func TestWithCompressionThreshold(t *testing.T) {
+	// A batch holding a NetworkSettings packet with a threshold of 512 and the flate algorithm.
+	batch := []byte{0xfe, 0x0c, 0x8f, 0x01, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	settings, ok := withCompressionThreshold(batch, 256)
+	if !ok {
+		t.Fatalf("NetworkSettings packet not found in batch")
+	}
+	if settings[4] != 0x00 || settings[5] != 0x01 {
+		t.Fatalf("threshold not replaced: % x", settings)
+	}
+	if batch[5] != 0x02 {
+		t.Fatalf("batch passed was changed")
+	}
+	if _, ok := withCompressionThreshold([]byte{0xfe, 0x02, 0x01, 0x00}, 256); ok {
+		t.Fatalf("threshold replaced in batch without NetworkSettings packet")
+	}
+}
@@ -6,6 +6,57 @@ import (
 	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
 )
 
+const (
+	InputFlagAscend = 1 << iota
+	InputFlagDescend
+	InputFlagNorthJump
+	InputFlagJumpDown
+	InputFlagSprintDown
+	InputFlagChangeHeight
+	InputFlagJumping
+	InputFlagAutoJumpingInWater
+	InputFlagSneaking
+	InputFlagSneakDown
+	InputFlagUp
+	InputFlagDown
+	InputFlagLeft
+	InputFlagRight
+	InputFlagUpLeft
+	InputFlagUpRight
+	InputFlagWantUp
+	InputFlagWantDown
+	InputFlagWantDownSlow
+	InputFlagWantUpSlow
+	InputFlagSprinting
+	InputFlagAscendBlock
+	InputFlagDescendBlock
+	InputFlagSneakToggleDown
+	InputFlagPersistSneak
+	InputFlagStartSprinting
+	InputFlagStopSprinting
+	InputFlagStartSneaking
+	InputFlagStopSneaking
+	InputFlagStartSwimming
+	InputFlagStopSwimming
+	InputFlagStartJumping
+	InputFlagStartGliding
+	InputFlagStopGliding
+	InputFlagPerformItemInteraction
+	InputFlagPerformBlockActions
+	InputFlagPerformItemStackRequest
+	InputFlagHandledTeleport
+	InputFlagEmoting
+	InputFlagMissedSwing
+	InputFlagStartCrawling
+	InputFlagStopCrawling
+	InputFlagStartFlying
+	InputFlagStopFlying
+	InputFlagClientAckServerData
+	InputFlagClientPredictedVehicle
+	InputFlagPaddlingLeft
+	InputFlagPaddlingRight
+)
+
 // PlayerAuthInput is sent by the client to allow for server authoritative movement. It is used to synchronise
 // the player input with the position server-side.
 // The client sends this packet when the ServerAuthoritativeMovementMode field in the StartGame packet is set
@@ -74,19 +125,19 @@ func (pk *PlayerAuthInput) Marshal(io protocol.IO) {
 	io.Varuint64(&pk.Tick)
 	io.Vec3(&pk.Delta)
 
-	if pk.InputData&packet.InputFlagPerformItemInteraction != 0 {
+	if pk.InputData&InputFlagPerformItemInteraction != 0 {
 		io.PlayerInventoryAction(&pk.ItemInteractionData)
 	}
 
-	if pk.InputData&packet.InputFlagPerformItemStackRequest != 0 {
+	if pk.InputData&InputFlagPerformItemStackRequest != 0 {
 		protocol.Single(io, &pk.ItemStackRequest)
 	}
 
-	if pk.InputData&packet.InputFlagPerformBlockActions != 0 {
+	if pk.InputData&InputFlagPerformBlockActions != 0 {
 		protocol.SliceVarint32Length(io, &pk.BlockActions)
 	}
 
-	if pk.InputData&packet.InputFlagClientPredictedVehicle != 0 {
+	if pk.InputData&InputFlagClientPredictedVehicle != 0 {
 		io.Varint64(&pk.ClientPredictedVehicle)
 	}
 
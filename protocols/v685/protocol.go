@@ -2,8 +2,15 @@ package v685
 
 import (
 	_ "embed"
+	"fmt"
 
+	"github.com/oomph-ac/new-mv/compat"
 	"github.com/oomph-ac/new-mv/internal/chunk"
+	"github.com/oomph-ac/new-mv/internal/component"
+	"github.com/oomph-ac/new-mv/internal/input"
+	"github.com/oomph-ac/new-mv/internal/movement"
+	"github.com/oomph-ac/new-mv/internal/session"
+	"github.com/oomph-ac/new-mv/internal/version"
 	"github.com/oomph-ac/new-mv/mapping"
 	"github.com/oomph-ac/new-mv/protocols/latest"
 	v685packet "github.com/oomph-ac/new-mv/protocols/v686/packet"
@@ -92,22 +99,58 @@ func init() {
 }
 
 type Protocol struct {
-	itemMapping     mapping.Item
-	blockMapping    mapping.Block
-	itemTranslator  translator.ItemTranslator
-	blockTranslator translator.BlockTranslator
+	*version.Base[*Protocol]
 }
 
-func New(direct bool) *Protocol {
-	itemMapping := mapping.NewItemMapping(itemRuntimeIDData, requiredItemList, ItemVersion, false)
-	blockMapping := mapping.NewBlockMapping(blockStateData)
-	latestBlockMapping := latest.NewBlockMapping()
-	return &Protocol{
-		itemMapping:     itemMapping,
-		blockMapping:    blockMapping,
-		itemTranslator:  translator.NewItemTranslator(itemMapping, latest.NewItemMapping(false), blockMapping, latestBlockMapping),
-		blockTranslator: translator.NewBlockTranslator(blockMapping, latestBlockMapping, chunk.NewNetworkPersistentEncoding(blockMapping, BlockVersion), chunk.NewBlockPaletteEncoding(blockMapping, BlockVersion), false),
+// NewItemMapping returns the item mapping of the version. If direct is true, it is loaded from the item
+// runtime ID NBT, and otherwise from the required item list.
+func NewItemMapping(direct bool) (mapping.Item, error) {
+	m, err := mapping.NewItemMapping(itemRuntimeIDData, requiredItemList, ItemVersion, direct)
+	if err != nil {
+		return nil, err
 	}
+	return m, nil
+}
+
+// New returns the protocol of the version. If direct is true, items are loaded from the item runtime ID NBT,
+// and otherwise from the required item list.
+func New(direct bool) (*Protocol, error) {
+	itemMapping, err := NewItemMapping(direct)
+	if err != nil {
+		return nil, fmt.Errorf("load item mapping: %w", err)
+	}
+	latestItemMapping, err := latest.NewItemMapping(direct)
+	if err != nil {
+		return nil, fmt.Errorf("load latest item mapping: %w", err)
+	}
+	blockMapping, err := mapping.NewBlockMapping(blockStateData)
+	if err != nil {
+		return nil, fmt.Errorf("load block mapping: %w", err)
+	}
+	latestBlockMapping, err := latest.NewBlockMapping()
+	if err != nil {
+		return nil, fmt.Errorf("load latest block mapping: %w", err)
+	}
+	blockTranslator := translator.NewBlockTranslator(blockMapping, latestBlockMapping, chunk.NewNetworkPersistentEncoding(blockMapping, BlockVersion), chunk.NewBlockPaletteEncoding(blockMapping, BlockVersion), false)
+	return version.New(version.Config{
+		ID:                      685,
+		ServerPackets:           packetPool_server,
+		ClientPackets:           packetPool_client,
+		Upgrade:                 ProtoUpgrade,
+		Downgrade:               ProtoDowngrade,
+		InputFlags:              input.Flags685,
+		SynthesizeLoadingScreen: true,
+		ItemTranslator:          translator.NewItemTranslator(itemMapping, latestItemMapping, blockTranslator).WithComponentDowngrader(component.Downgrader(685)),
+		BlockTranslator:         blockTranslator,
+	}, func(b *version.Base[*Protocol]) *Protocol {
+		return &Protocol{Base: b}
+	}), nil
+}
+
+// Unsupported returns the IDs of the packets sent by servers of the latest version that the version has no
+// packet for.
+func (Protocol) Unsupported() []uint32 {
+	return compat.Unsupported(packetPool_server)
 }
 
 func (Protocol) ID() int32 {
@@ -137,37 +180,35 @@ func (Protocol) NewWriter(w minecraft.ByteWriter, shieldID int32) protocol.IO {
 	return NewWriter(protocol.NewWriter(w, shieldID))
 }
 
-func (p Protocol) ConvertToLatest(pk packet.Packet, conn *minecraft.Conn) []packet.Packet {
-	return ProtoUpgrade(p.blockTranslator.UpgradeBlockPackets(
-		p.itemTranslator.UpgradeItemPackets([]packet.Packet{pk}, conn),
-		conn,
-	))
-}
-
-func ProtoUpgrade(pks []packet.Packet) []packet.Packet {
+func ProtoUpgrade(pks []packet.Packet, state *session.State) []packet.Packet {
 	for index, pk := range pks {
 		switch pk := pk.(type) {
 		case *v685packet.Emote:
 			pks[index] = &packet.Emote{
 				EntityRuntimeID: pk.EntityRuntimeID,
 				EmoteID:         pk.EmoteID,
-				EmoteLength:     100, // TODO: ???
+				EmoteLength:     state.EmoteLength(pk.EmoteID),
 				XUID:            pk.XUID,
 				PlatformID:      pk.PlatformID,
 				Flags:           pk.Flags,
 			}
 		case *v685packet.EditorNetwork:
 			pks[index] = &packet.EditorNetwork{
-				RouteToManager: false,
+				RouteToManager: state.RouteToManager(),
 				Payload:        pk.Payload,
 			}
 		case *v685packet.MobArmourEquipment:
+			chestplate, body := pk.Chestplate, protocol.ItemInstance{}
+			if state.BodyArmourInChestplate(pk.EntityRuntimeID) {
+				chestplate, body = body, pk.Chestplate
+			}
 			pks[index] = &packet.MobArmourEquipment{
 				EntityRuntimeID: pk.EntityRuntimeID,
 				Helmet:          pk.Helmet,
-				Chestplate:      pk.Chestplate,
+				Chestplate:      chestplate,
 				Leggings:        pk.Leggings,
 				Boots:           pk.Boots,
+				Body:            body,
 			}
 		case *packet.InventoryTransaction:
 			var transactionData protocol.InventoryTransactionData = pk.TransactionData
@@ -196,18 +237,19 @@ func ProtoUpgrade(pks []packet.Packet) []packet.Packet {
 				}
 			}
 		case *v685packet.PlayerAuthInput:
+			interactPitch, interactYaw := input.InteractRotation(pk.Pitch, pk.HeadYaw, pk.PlayMode, pk.GazeDirection)
 			pks[index] = &packet.PlayerAuthInput{
 				Pitch:                  pk.Pitch,
 				Yaw:                    pk.Yaw,
 				Position:               pk.Position,
 				MoveVector:             pk.MoveVector,
 				HeadYaw:                pk.HeadYaw,
-				InputData:              pk.InputData,
+				InputData:              input.Flags685.Upgrade(pk.InputData),
 				InputMode:              pk.InputMode,
 				PlayMode:               pk.PlayMode,
 				InteractionModel:       pk.InteractionModel,
-				InteractPitch:          pk.GazeDirection.X(),
-				InteractYaw:            pk.GazeDirection.Y(),
+				InteractPitch:          interactPitch,
+				InteractYaw:            interactYaw,
 				Tick:                   pk.Tick,
 				Delta:                  pk.Delta,
 				ItemInteractionData:    pk.ItemInteractionData,
@@ -216,6 +258,7 @@ func ProtoUpgrade(pks []packet.Packet) []packet.Packet {
 				VehicleRotation:        pk.VehicleRotation,
 				ClientPredictedVehicle: pk.ClientPredictedVehicle,
 				AnalogueMoveVector:     pk.AnalogueMoveVector,
+				CameraOrientation:      input.CameraOrientation(interactPitch, interactYaw),
 			}
 		}
 	}
@@ -223,14 +266,8 @@ func ProtoUpgrade(pks []packet.Packet) []packet.Packet {
 	return pks
 }
 
-func (p Protocol) ConvertFromLatest(pk packet.Packet, conn *minecraft.Conn) []packet.Packet {
-	return ProtoDowngrade(p.blockTranslator.DowngradeBlockPackets(
-		p.itemTranslator.DowngradeItemPackets([]packet.Packet{pk}, conn),
-		conn,
-	))
-}
-
-func ProtoDowngrade(pks []packet.Packet) []packet.Packet {
+func ProtoDowngrade(pks []packet.Packet, state *session.State) []packet.Packet {
+	pks = movement.Downgrade(pks, state)
 	for index, pk := range pks {
 		switch pk := pk.(type) {
 		case *packet.AddActor:
@@ -371,10 +408,15 @@ func ProtoDowngrade(pks []packet.Packet) []packet.Packet {
 				Responses: responses,
 			}
 		case *packet.MobArmourEquipment:
+			// Body armour has no slot of its own in this version and is shown in the chestplate slot instead.
+			chestplate := pk.Chestplate
+			if state.BodyArmourInChestplate(pk.EntityRuntimeID) {
+				chestplate = pk.Body
+			}
 			pks[index] = &v685packet.MobArmourEquipment{
 				EntityRuntimeID: pk.EntityRuntimeID,
 				Helmet:          pk.Helmet,
-				Chestplate:      pk.Chestplate,
+				Chestplate:      chestplate,
 				Leggings:        pk.Leggings,
 				Boots:           pk.Boots,
 			}
@@ -393,8 +435,12 @@ func ProtoDowngrade(pks []packet.Packet) []packet.Packet {
 			if pk.Bitset&packet.PlayerArmourDamageFlagHelmet != 0 {
 				bitset = 0b0001
 			}
+			chestplateDamage := pk.ChestplateDamage
 			if pk.Bitset&packet.PlayerArmourDamageFlagChestplate != 0 {
 				bitset = bitset | 0b0010
+			} else if pk.Bitset&packet.PlayerArmourDamageFlagBody != 0 {
+				// Body armour is shown in the chestplate slot when there is no chestplate.
+				bitset, chestplateDamage = bitset|0b0010, pk.BodyDamage
 			}
 			if pk.Bitset&packet.PlayerArmourDamageFlagLeggings != 0 {
 				bitset = bitset | 0b0100
@@ -406,7 +452,7 @@ func ProtoDowngrade(pks []packet.Packet) []packet.Packet {
 			pks[index] = &v685packet.PlayerArmourDamage{
 				Bitset:           bitset,
 				HelmetDamage:     pk.HelmetDamage,
-				ChestplateDamage: pk.ChestplateDamage,
+				ChestplateDamage: chestplateDamage,
 				LeggingsDamage:   pk.LeggingsDamage,
 				BootsDamage:      pk.BootsDamage,
 			}
@@ -480,6 +526,5 @@ func ProtoDowngrade(pks []packet.Packet) []packet.Packet {
 			}
 		}
 	}
-
 	return pks
 }
@@ -0,0 +1,97 @@
+package v686
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/oomph-ac/new-mv/internal/session"
+	v686packet "github.com/oomph-ac/new-mv/protocols/v686/packet"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// Entity runtime IDs of the entities in the tests: a wolf wearing only wolf armour, and a player wearing a
+// chestplate.
+const wolf, player = 2, 3
+
+var (
+	wolfArmour = protocol.ItemInstance{StackNetworkID: 1, Stack: protocol.ItemStack{ItemType: protocol.ItemType{NetworkID: 10}, Count: 1}}
+	chestplate = protocol.ItemInstance{StackNetworkID: 2, Stack: protocol.ItemStack{ItemType: protocol.ItemType{NetworkID: 20}, Count: 1}}
+)
+
+// armourState returns the state of a connection that was sent the armour of the wolf and the player.
+func armourState() *session.State {
+	state := session.NewState()
+	state.Observe(&packet.MobArmourEquipment{EntityRuntimeID: wolf, Body: wolfArmour})
+	state.Observe(&packet.MobArmourEquipment{EntityRuntimeID: player, Chestplate: chestplate})
+	return state
+}
+
+func TestDowngradeBodyArmour(t *testing.T) {
+	tests := []struct {
+		name string
+		pk   packet.Packet
+		want packet.Packet
+	}{
+		{
+			name: "body armour shown as chestplate",
+			pk:   &packet.MobArmourEquipment{EntityRuntimeID: wolf, Body: wolfArmour},
+			want: &v686packet.MobArmourEquipment{EntityRuntimeID: wolf, Chestplate: wolfArmour},
+		},
+		{
+			name: "chestplate kept",
+			pk:   &packet.MobArmourEquipment{EntityRuntimeID: player, Chestplate: chestplate},
+			want: &v686packet.MobArmourEquipment{EntityRuntimeID: player, Chestplate: chestplate},
+		},
+		{
+			name: "body armour damaged as chestplate",
+			pk:   &packet.PlayerArmourDamage{Bitset: packet.PlayerArmourDamageFlagBody | packet.PlayerArmourDamageFlagHelmet, HelmetDamage: 1, BodyDamage: 4},
+			want: &v686packet.PlayerArmourDamage{Bitset: 0b0011, HelmetDamage: 1, ChestplateDamage: 4},
+		},
+		{
+			name: "chestplate damaged over body armour",
+			pk:   &packet.PlayerArmourDamage{Bitset: packet.PlayerArmourDamageFlagChestplate | packet.PlayerArmourDamageFlagBody, ChestplateDamage: 2, BodyDamage: 4},
+			want: &v686packet.PlayerArmourDamage{Bitset: 0b0010, ChestplateDamage: 2},
+		},
+		{
+			name: "other armour damaged",
+			pk:   &packet.PlayerArmourDamage{Bitset: packet.PlayerArmourDamageFlagLeggings | packet.PlayerArmourDamageFlagBoots, LeggingsDamage: 3, BootsDamage: 5},
+			want: &v686packet.PlayerArmourDamage{Bitset: 0b1100, LeggingsDamage: 3, BootsDamage: 5},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pks := ProtoDowngrade([]packet.Packet{test.pk}, armourState())
+			if len(pks) != 1 || !reflect.DeepEqual(pks[0], test.want) {
+				t.Fatalf("downgraded to %#v, expected %#v", pks, test.want)
+			}
+		})
+	}
+}
+
+func TestUpgradeBodyArmour(t *testing.T) {
+	tests := []struct {
+		name string
+		pk   packet.Packet
+		want packet.Packet
+	}{
+		{
+			name: "chestplate of entity with body armour moved to body",
+			pk:   &v686packet.MobArmourEquipment{EntityRuntimeID: wolf, Chestplate: wolfArmour},
+			want: &packet.MobArmourEquipment{EntityRuntimeID: wolf, Body: wolfArmour},
+		},
+		{
+			name: "chestplate kept",
+			pk:   &v686packet.MobArmourEquipment{EntityRuntimeID: player, Chestplate: chestplate},
+			want: &packet.MobArmourEquipment{EntityRuntimeID: player, Chestplate: chestplate},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pks := ProtoUpgrade([]packet.Packet{test.pk}, armourState())
+			if len(pks) != 1 || !reflect.DeepEqual(pks[0], test.want) {
+				t.Fatalf("upgraded to %#v, expected %#v", pks, test.want)
+			}
+		})
+	}
+}
@@ -19,6 +19,9 @@ type ResourcePacksInfo struct {
 	HasScripts bool
 	// BehaviourPack is a list of behaviour packs that the client needs to download before joining the server.
 	// All of these behaviour packs will be applied together.
+	// Servers of the latest version cannot send behaviour packs, so it is always empty when sent to a client.
+	// Behaviour packs sent by a server of this version are dropped and reported as lost fields when the packet
+	// is converted for a client of the latest version.
 	BehaviourPacks []types.TexturePackInfo
 	// TexturePacks is a list of texture packs that the client needs to download before joining the server.
 	// The order of these texture packs is not relevant in this packet. It is however important in the
@@ -41,9 +44,6 @@ func (pk *ResourcePacksInfo) Marshal(io protocol.IO) {
 	io.Bool(&pk.HasAddons)
 	io.Bool(&pk.HasScripts)
 	io.Bool(&pk.ForcingServerPacks)
-	if len(pk.BehaviourPacks) > 0 {
-		panic("v712_ResourcePacksInfo: behavior pack list should be empty when downgrading")
-	}
 	protocol.SliceUint16Length(io, &pk.BehaviourPacks)
 	protocol.SliceUint16Length(io, &pk.TexturePacks)
 	protocol.Slice(io, &pk.PackURLs)
@@ -0,0 +1,131 @@
+// Package trace implements a Tracer that logs the conversions of packets between protocol versions, along with
+// the fallbacks taken and errors encountered while converting them. It is meant for debugging client reports
+// without changing the library.
+package trace
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// Direction is the direction in which a packet is converted.
+type Direction string
+
+const (
+	// Upgrade is the direction of packets converted from a legacy version to the latest version.
+	Upgrade Direction = "upgrade"
+	// Downgrade is the direction of packets converted from the latest version to a legacy version.
+	Downgrade Direction = "downgrade"
+)
+
+// Tracer logs conversions, fallbacks and errors to a slog.Logger. A nil *Tracer is valid and logs nothing, so
+// that it may be used without checking if tracing is enabled.
+type Tracer struct {
+	log *slog.Logger
+	// sample is the amount of conversions of which one is logged.
+	sample uint64
+	count  atomic.Uint64
+}
+
+// New returns a Tracer logging to the logger passed. Only one in every sample conversions is logged, while
+// fallbacks and errors are always logged. A sample of 0 or 1 logs every conversion.
+func New(log *slog.Logger, sample uint64) *Tracer {
+	return &Tracer{log: log, sample: max(sample, 1)}
+}
+
+// Conversion is a single conversion of a packet started using Tracer.Start.
+type Conversion struct {
+	t         *Tracer
+	conn      *minecraft.Conn
+	protocol  int32
+	direction Direction
+	source    string
+}
+
+// Start starts tracing the conversion of the packet passed by the protocol with the ID passed. The Conversion
+// returned must be ended once the packet has been converted. Start must be called before the packet is
+// converted, as conversions may change it in place.
+func (t *Tracer) Start(conn *minecraft.Conn, protocol int32, direction Direction, pk packet.Packet) Conversion {
+	if t == nil || !t.log.Enabled(context.Background(), slog.LevelDebug) || t.count.Add(1)%t.sample != 0 {
+		return Conversion{}
+	}
+	return Conversion{t: t, conn: conn, protocol: protocol, direction: direction, source: typeName(pk)}
+}
+
+// End logs the conversion with the packets it resulted in.
+func (c Conversion) End(pks []packet.Packet) {
+	if c.t == nil {
+		return
+	}
+	targets := make([]string, len(pks))
+	for i, pk := range pks {
+		targets[i] = typeName(pk)
+	}
+	c.t.log.LogAttrs(context.Background(), slog.LevelDebug, "converted packet",
+		append(connAttrs(c.conn),
+			slog.Int("protocol", int(c.protocol)),
+			slog.String("direction", string(c.direction)),
+			slog.String("source", c.source),
+			slog.String("targets", strings.Join(targets, ", ")),
+			slog.Int("in", 1),
+			slog.Int("out", len(pks)),
+		)...,
+	)
+}
+
+// Fallback logs that a conversion could not translate something exactly and fell back to an approximation or
+// left it out. The arguments passed are added to the log record like those of slog.Logger.Info.
+func (t *Tracer) Fallback(conn *minecraft.Conn, msg string, args ...any) {
+	if t == nil {
+		return
+	}
+	t.log.With(attrsToArgs(connAttrs(conn))...).Info(msg, args...)
+}
+
+// Error logs an error encountered during a conversion, after which the data affected was passed on as is.
+func (t *Tracer) Error(conn *minecraft.Conn, msg string, err error) {
+	if t == nil {
+		return
+	}
+	t.log.LogAttrs(context.Background(), slog.LevelWarn, msg, append(connAttrs(conn), slog.Any("error", err))...)
+}
+
+// connAttrs returns the attributes identifying the connection passed.
+func connAttrs(conn *minecraft.Conn) []slog.Attr {
+	if conn == nil {
+		return nil
+	}
+	attrs := []slog.Attr{slog.String("addr", conn.RemoteAddr().String())}
+	if name := conn.IdentityData().DisplayName; name != "" {
+		attrs = append(attrs, slog.String("name", name))
+	}
+	return attrs
+}
+
+// attrsToArgs converts the attributes passed to arguments accepted by slog.Logger.With.
+func attrsToArgs(attrs []slog.Attr) []any {
+	args := make([]any, len(attrs))
+	for i, attr := range attrs {
+		args[i] = attr
+	}
+	return args
+}
+
+// typeName returns the name of the type of the packet passed. Packets of legacy versions are prefixed with the
+// version they belong to, such as v630/packet.Emote, to tell them apart from packets of the latest version.
+func typeName(pk packet.Packet) string {
+	t := reflect.TypeOf(pk)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if _, version, ok := strings.Cut(t.PkgPath(), "/protocols/"); ok {
+		return version + "." + t.Name()
+	}
+	return t.String()
+}
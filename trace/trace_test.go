@@ -0,0 +1,95 @@
+package trace
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	v630packet "github.com/oomph-ac/new-mv/protocols/v630/packet"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+func TestTracer(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  slog.Level
+		sample uint64
+		// trace traces a number of conversions, fallbacks or errors using the tracer passed.
+		trace func(t *Tracer)
+		// want holds the lines expected to be logged, each given as the substrings it must hold.
+		want [][]string
+	}{
+		{
+			name:  "conversion",
+			level: slog.LevelDebug,
+			trace: func(t *Tracer) {
+				t.Start(nil, 630, Downgrade, &packet.Emote{}).End([]packet.Packet{&v630packet.Emote{}, &packet.Text{}})
+			},
+			want: [][]string{{`msg="converted packet"`, "protocol=630", "direction=downgrade", "source=packet.Emote", `targets="v630/packet.Emote, packet.Text"`, "in=1", "out=2"}},
+		},
+		{
+			name:   "conversions sampled",
+			level:  slog.LevelDebug,
+			sample: 3,
+			trace: func(t *Tracer) {
+				for i := 0; i < 6; i++ {
+					t.Start(nil, 630, Upgrade, &v630packet.Emote{}).End(nil)
+				}
+			},
+			want: [][]string{{"direction=upgrade", "source=v630/packet.Emote", "out=0"}, {"direction=upgrade", "source=v630/packet.Emote", "out=0"}},
+		},
+		{
+			name:  "conversions not logged above debug level",
+			level: slog.LevelInfo,
+			trace: func(t *Tracer) {
+				t.Start(nil, 630, Downgrade, &packet.Emote{}).End(nil)
+			},
+		},
+		{
+			name:  "fallback",
+			level: slog.LevelInfo,
+			trace: func(t *Tracer) {
+				t.Fallback(nil, "dropped recipes", "count", 3)
+			},
+			want: [][]string{{"level=INFO", `msg="dropped recipes"`, "count=3"}},
+		},
+		{
+			name:  "error",
+			level: slog.LevelInfo,
+			trace: func(t *Tracer) {
+				t.Error(nil, "decode chunk", errors.New("unexpected EOF"))
+			},
+			want: [][]string{{"level=WARN", `msg="decode chunk"`, `error="unexpected EOF"`}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			test.trace(New(slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: test.level})), test.sample))
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if buf.Len() == 0 {
+				lines = nil
+			}
+			if len(lines) != len(test.want) {
+				t.Fatalf("logged %v lines, expected %v:\n%v", len(lines), len(test.want), buf)
+			}
+			for i, line := range lines {
+				for _, want := range test.want[i] {
+					if !strings.Contains(line, want) {
+						t.Errorf("line %q does not hold %q", line, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	tracer.Start(nil, 630, Downgrade, &packet.Emote{}).End(nil)
+	tracer.Fallback(nil, "fallback")
+	tracer.Error(nil, "error", errors.New("error"))
+}
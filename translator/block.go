@@ -2,12 +2,18 @@ package translator
 
 import (
 	"bytes"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/df-mc/dragonfly/server/block/cube"
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/oomph-ac/new-mv/internal/chunk"
+	"github.com/oomph-ac/new-mv/internal/session"
 	"github.com/oomph-ac/new-mv/mapping"
+	"github.com/oomph-ac/new-mv/metrics"
 	"github.com/oomph-ac/new-mv/protocols/latest"
+	"github.com/oomph-ac/new-mv/trace"
 	"github.com/sandertv/gophertunnel/minecraft"
 	"github.com/sandertv/gophertunnel/minecraft/nbt"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
@@ -26,108 +32,142 @@ type DefaultBlockTranslator struct {
 	latest    mapping.Block
 	pse       chunk.Encoding
 	pe        chunk.PaletteEncoding
+	latestPse chunk.Encoding
+	latestPe  chunk.PaletteEncoding
 	oldFormat bool
+	sessions  *session.Store[blockSession]
+	tracer    *trace.Tracer
+	metrics   *metrics.Reporter
+
+	errorHandler func(conn *minecraft.Conn, pk packet.Packet, err error)
+}
+
+// blockSession holds the block mappings of a single connection, adjusted to the custom states sent to it, and
+// the chunk encodings using them.
+type blockSession struct {
+	mu        sync.Mutex
+	mapping   mapping.Block
+	latest    mapping.Block
+	pse       chunk.Encoding
+	pe        chunk.PaletteEncoding
+	latestPse chunk.Encoding
+	latestPe  chunk.PaletteEncoding
 }
 
 func NewBlockTranslator(mapping mapping.Block, latestMapping mapping.Block, pse chunk.Encoding, pe chunk.PaletteEncoding, oldFormat bool) *DefaultBlockTranslator {
-	return &DefaultBlockTranslator{mapping: mapping, latest: latestMapping, pse: pse, pe: pe, oldFormat: oldFormat}
+	return &DefaultBlockTranslator{mapping: mapping, latest: latestMapping, pse: pse, pe: pe, oldFormat: oldFormat,
+		latestPse: chunk.NewNetworkPersistentEncoding(latestMapping, latest.BlockVersion), latestPe: chunk.NewBlockPaletteEncoding(latestMapping, latest.BlockVersion),
+		sessions: session.NewStore(func() *blockSession { return &blockSession{} })}
 }
 
-func (t *DefaultBlockTranslator) DowngradeBlockPackets(pks []packet.Packet, conn *minecraft.Conn) (result []packet.Packet) {
-	for _, pk := range pks {
-		switch pk := pk.(type) {
-		case *packet.LevelChunk:
-			count := int(pk.SubChunkCount)
-			if count == protocol.SubChunkRequestModeLimitless || count == protocol.SubChunkRequestModeLimited {
-				break
-			}
+// SetTracer sets the tracer that fallbacks and errors during translation are reported to.
+func (t *DefaultBlockTranslator) SetTracer(tracer *trace.Tracer) {
+	t.tracer = tracer
+}
 
-			buf := bytes.NewBuffer(pk.RawPayload)
-			writeBuf := bytes.NewBuffer(nil)
-			if !pk.CacheEnabled {
-				c, err := chunk.NetworkDecode(t.latest.Air(), buf, count, false, world.Overworld.Range(), latest.NetworkPersistentEncoding, latest.BlockPaletteEncoding)
-				if err != nil {
-					//fmt.Println(err)
-					break
-				}
-				c = t.DowngradeChunk(c)
+// SetMetrics sets the reporter that measurements of translation are reported to.
+func (t *DefaultBlockTranslator) SetMetrics(reporter *metrics.Reporter) {
+	t.metrics = reporter
+}
 
-				payload, err := chunk.NetworkEncode(t.mapping.Air(), c, t.oldFormat, t.pe)
-				if err != nil {
-					//fmt.Println(err)
-					break
-				}
-				writeBuf.Write(payload)
-				pk.SubChunkCount = uint32(len(c.Sub()))
-			}
-			safeBytes := buf.Bytes()
+// SetErrorHandler sets the function that errors translating a packet are reported to. The packet is still sent,
+// with the blocks that could not be translated left out. If no handler is set, errors are only traced.
+func (t *DefaultBlockTranslator) SetErrorHandler(h func(conn *minecraft.Conn, pk packet.Packet, err error)) {
+	t.errorHandler = h
+}
 
-			countBorder, err := buf.ReadByte()
-			if err != nil {
-				pk.RawPayload = append(writeBuf.Bytes(), safeBytes...)
-				break
-			}
-			borderBytes := make([]byte, countBorder)
-			if _, err = buf.Read(borderBytes); err != nil {
-				pk.RawPayload = append(writeBuf.Bytes(), safeBytes...)
-				break
-			}
-			writeBuf.WriteByte(countBorder)
-			writeBuf.Write(borderBytes)
-
-			enc := nbt.NewEncoderWithEncoding(writeBuf, nbt.NetworkLittleEndian)
-			dec := nbt.NewDecoderWithEncoding(buf, nbt.NetworkLittleEndian)
-			for {
-				var decNbt map[string]any
-				if err = dec.Decode(&decNbt); err != nil {
-					break
-				}
-				t.mapping.DowngradeBlockActorData(decNbt)
+// reportError reports an error translating the packet passed to the error handler of the translator.
+func (t *DefaultBlockTranslator) reportError(conn *minecraft.Conn, pk packet.Packet, msg string, err error) {
+	if t.errorHandler == nil {
+		t.tracer.Error(conn, msg, err)
+		return
+	}
+	t.errorHandler(conn, pk, fmt.Errorf("%v: %w", msg, err))
+}
 
-				if err = enc.Encode(decNbt); err != nil {
-					break
-				}
-			}
-			pk.RawPayload = append(writeBuf.Bytes(), buf.Bytes()...)
-		case *packet.SubChunk:
-			r := world.Overworld.Range()
-			if t.oldFormat {
-				r = cube.Range{0, 255}
-			}
+// Fork returns a translator sharing the block mappings of t. Custom block states sent by the servers of its
+// connections are not visible to t or to other forks.
+func (t *DefaultBlockTranslator) Fork() *DefaultBlockTranslator {
+	fork := NewBlockTranslator(t.mapping, t.latest, t.pse, t.pe, t.oldFormat)
+	fork.tracer, fork.metrics = t.tracer, t.metrics
+	return fork
+}
 
-			for i, entry := range pk.SubChunkEntries {
-				if entry.Result == protocol.SubChunkResultSuccess {
-					buf := bytes.NewBuffer(entry.RawPayload)
-					writeBuf := bytes.NewBuffer(nil)
-					if !pk.CacheEnabled && !conn.ClientCacheEnabled() {
-						ind := byte(i)
-						subChunk, err := chunk.DecodeSubChunk(t.latest.Air(), r, buf, &ind, chunk.NetworkEncoding, latest.NetworkPersistentEncoding, latest.BlockPaletteEncoding)
-						if err != nil {
-							//fmt.Println(err)
-							continue
-						}
-						t.DowngradeSubChunk(subChunk)
-						writeBuf.Write(chunk.EncodeSubChunk(subChunk, chunk.NetworkEncoding, t.pe, chunk.SubChunkVersion9, r, int(ind)))
-					}
-
-					enc := nbt.NewEncoderWithEncoding(writeBuf, nbt.NetworkLittleEndian)
-					dec := nbt.NewDecoderWithEncoding(buf, nbt.NetworkLittleEndian)
-					for {
-						var decNbt map[string]any
-						if err := dec.Decode(&decNbt); err != nil {
-							break
-						}
-						t.mapping.DowngradeBlockActorData(decNbt)
-
-						if err := enc.Encode(decNbt); err != nil {
-							break
-						}
-					}
-
-					entry.RawPayload = append(writeBuf.Bytes(), buf.Bytes()...)
-					pk.SubChunkEntries[i] = entry
-				}
-			}
+// Reset forgets the custom block states sent over the connection passed.
+func (t *DefaultBlockTranslator) Reset(conn *minecraft.Conn) {
+	t.sessions.Delete(conn)
+}
+
+// forConn returns the translator to use for the connection passed. If custom states were sent over the
+// connection, it is a copy of t using the block mappings adjusted to them.
+func (t *DefaultBlockTranslator) forConn(conn *minecraft.Conn) *DefaultBlockTranslator {
+	s := t.sessions.Get(conn)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.latest == nil {
+		return t
+	}
+	adjusted := *t
+	adjusted.mapping, adjusted.latest = s.mapping, s.latest
+	adjusted.pse, adjusted.pe, adjusted.latestPse, adjusted.latestPe = s.pse, s.pe, s.latestPse, s.latestPe
+	return &adjusted
+}
+
+// mappings returns the legacy and latest block mappings used for the connection passed.
+func (t *DefaultBlockTranslator) mappings(conn *minecraft.Conn) (legacy, latest mapping.Block) {
+	s := t.sessions.Get(conn)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.latest == nil {
+		return t.mapping, t.latest
+	}
+	return s.mapping, s.latest
+}
+
+// adjust adjusts the block mappings of the connection passed to the custom states of a StartGame packet and
+// returns the translator to use for the connection from then on.
+func (t *DefaultBlockTranslator) adjust(conn *minecraft.Conn, pk *packet.StartGame) *DefaultBlockTranslator {
+	latest, legacy, err := adjustBlockMappings(t.latest, t.mapping, pk.Blocks)
+	if err != nil {
+		// The custom states are left out rather than failing the connection. They will become air.
+		t.reportError(conn, pk, "adjust block mappings", err)
+		return t
+	}
+
+	s := t.sessions.Get(conn)
+	s.mu.Lock()
+	s.latest, s.mapping = latest, legacy
+	s.pse, s.pe = chunk.WithBlockMapping(t.pse, t.pe, legacy)
+	s.latestPse, s.latestPe = chunk.WithBlockMapping(t.latestPse, t.latestPe, latest)
+	s.mu.Unlock()
+	return t.forConn(conn)
+}
+
+// adjustBlockMappings returns the latest and legacy block mappings passed adjusted to the custom states passed.
+// If both are the same mapping, so are the adjusted mappings returned.
+func adjustBlockMappings(latest, legacy mapping.Block, entries []protocol.BlockEntry) (mapping.Block, mapping.Block, error) {
+	adjusted, err := latest.Adjust(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	if legacy == latest {
+		return adjusted, adjusted, nil
+	}
+	adjustedLegacy, err := legacy.Adjust(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	return adjusted, adjustedLegacy, nil
+}
+
+func (t *DefaultBlockTranslator) DowngradeBlockPackets(pks []packet.Packet, conn *minecraft.Conn) (result []packet.Packet) {
+	t = t.forConn(conn)
+	for _, pk := range pks {
+		switch pk := pk.(type) {
+		case *packet.LevelChunk:
+			t.translateLevelChunk(conn, pk, false)
+		case *packet.SubChunk:
+			t.translateSubChunk(conn, pk, false)
 		case *packet.ClientCacheMissResponse:
 			r := world.Overworld.Range()
 			if t.oldFormat {
@@ -137,7 +177,7 @@ func (t *DefaultBlockTranslator) DowngradeBlockPackets(pks []packet.Packet, conn
 			for i, blob := range pk.Blobs {
 				buf := bytes.NewBuffer(blob.Payload)
 				ind := byte(0)
-				subChunk, err := chunk.DecodeSubChunk(t.latest.Air(), r, buf, &ind, chunk.NetworkEncoding, latest.NetworkPersistentEncoding, latest.BlockPaletteEncoding)
+				subChunk, err := chunk.DecodeSubChunk(t.latest.Air(), r, buf, &ind, chunk.NetworkEncoding, t.latestPse, t.latestPe)
 				if err != nil {
 					// Has a possibility to be a biome, ignore then
 					continue
@@ -198,8 +238,7 @@ func (t *DefaultBlockTranslator) DowngradeBlockPackets(pks []packet.Packet, conn
 		case *packet.SetActorData:
 			pk.EntityMetadata = t.downgradeEntityMetadata(pk.EntityMetadata)
 		case *packet.StartGame:
-			t.latest.Adjust(pk.Blocks)
-			t.mapping.Adjust(pk.Blocks)
+			t = t.adjust(conn, pk)
 		case *packet.ResourcePackStack:
 			var packs []protocol.StackResourcePack
 			for _, pack := range pk.TexturePacks {
@@ -216,31 +255,219 @@ func (t *DefaultBlockTranslator) DowngradeBlockPackets(pks []packet.Packet, conn
 }
 
 func (t *DefaultBlockTranslator) UpgradeBlockPackets(pks []packet.Packet, conn *minecraft.Conn) (result []packet.Packet) {
+	t = t.forConn(conn)
 	for _, pk := range pks {
 		switch pk := pk.(type) {
+		case *packet.LevelChunk:
+			// Chunks are only upgraded when dialing a server of this version.
+			t.translateLevelChunk(conn, pk, true)
+		case *packet.SubChunk:
+			t.translateSubChunk(conn, pk, true)
+		case *packet.UpdateSubChunkBlocks:
+			for i, block := range pk.Blocks {
+				block.BlockRuntimeID = t.UpgradeBlockRuntimeID(block.BlockRuntimeID)
+				pk.Blocks[i] = block
+			}
+			for i, block := range pk.Extra {
+				block.BlockRuntimeID = t.UpgradeBlockRuntimeID(block.BlockRuntimeID)
+				pk.Extra[i] = block
+			}
+		case *packet.UpdateBlock:
+			pk.NewBlockRuntimeID = t.UpgradeBlockRuntimeID(pk.NewBlockRuntimeID)
+		case *packet.UpdateBlockSynced:
+			pk.NewBlockRuntimeID = t.UpgradeBlockRuntimeID(pk.NewBlockRuntimeID)
 		case *packet.InventoryTransaction:
 			if transactionData, ok := pk.TransactionData.(*protocol.UseItemTransactionData); ok {
 				transactionData.BlockRuntimeID = t.UpgradeBlockRuntimeID(transactionData.BlockRuntimeID)
 				pk.TransactionData = transactionData
 			}
+		case *packet.LevelEvent:
+			switch pk.EventType {
+			case packet.LevelEventParticleLegacyEvent | 20: // terrain
+				fallthrough
+			case packet.LevelEventParticlesDestroyBlock:
+				fallthrough
+			case packet.LevelEventParticlesDestroyBlockNoSound:
+				pk.EventData = int32(t.UpgradeBlockRuntimeID(uint32(pk.EventData)))
+			case packet.LevelEventParticlesCrackBlock:
+				face := pk.EventData >> 24
+				rid := t.UpgradeBlockRuntimeID(uint32(pk.EventData & 0xffff))
+				pk.EventData = int32(rid) | (face << 24)
+			}
+		case *packet.LevelSoundEvent:
+			switch pk.SoundType {
+			case packet.SoundEventBreak:
+				fallthrough
+			case packet.SoundEventPlace:
+				fallthrough
+			case packet.SoundEventHit:
+				fallthrough
+			case packet.SoundEventLand:
+				fallthrough
+			case packet.SoundEventItemUseOn:
+				pk.ExtraData = int32(t.UpgradeBlockRuntimeID(uint32(pk.ExtraData)))
+			}
+		case *packet.AddActor:
+			if pk.EntityType == "minecraft:falling_block" {
+				pk.EntityMetadata = t.upgradeEntityMetadata(pk.EntityMetadata)
+			}
 		case *packet.SetActorData:
 			pk.EntityMetadata = t.upgradeEntityMetadata(pk.EntityMetadata)
+		case *packet.StartGame:
+			// StartGame is only upgraded when dialing a server of this version.
+			t = t.adjust(conn, pk)
 		}
 		result = append(result, pk)
 	}
 	return result
 }
 
+// chunkFormat is the format that the chunks of a version are encoded in.
+type chunkFormat struct {
+	air       uint32
+	oldFormat bool
+	pse       chunk.Encoding
+	pe        chunk.PaletteEncoding
+}
+
+// chunkFormats returns the format of the chunks translated from and the format of the chunks translated to. If
+// upgrade is true, chunks are translated from this version to the latest, and otherwise the other way around.
+func (t *DefaultBlockTranslator) chunkFormats(upgrade bool) (from, to chunkFormat) {
+	legacy := chunkFormat{air: t.mapping.Air(), oldFormat: t.oldFormat, pse: t.pse, pe: t.pe}
+	latest := chunkFormat{air: t.latest.Air(), pse: t.latestPse, pe: t.latestPe}
+	if upgrade {
+		return legacy, latest
+	}
+	return latest, legacy
+}
+
+// translateLevelChunk translates the blocks and block actors of the LevelChunk passed in place. If upgrade is
+// true, the chunk is upgraded from this version to the latest, and otherwise downgraded.
+func (t *DefaultBlockTranslator) translateLevelChunk(conn *minecraft.Conn, pk *packet.LevelChunk, upgrade bool) {
+	count := int(pk.SubChunkCount)
+	if count == protocol.SubChunkRequestModeLimitless || count == protocol.SubChunkRequestModeLimited {
+		return
+	}
+	from, to := t.chunkFormats(upgrade)
+
+	buf := bytes.NewBuffer(pk.RawPayload)
+	writeBuf := bytes.NewBuffer(nil)
+	if !pk.CacheEnabled {
+		c, err := chunk.NetworkDecode(from.air, buf, count, from.oldFormat, world.Overworld.Range(), from.pse, from.pe)
+		if err != nil {
+			t.reportError(conn, pk, "decode level chunk", err)
+			t.metrics.Add(metrics.ChunkErrors, 1)
+			return
+		}
+		if upgrade {
+			t.UpgradeChunk(c)
+		} else {
+			start := time.Now()
+			c = t.DowngradeChunk(c)
+			t.metrics.Observe(metrics.ChunkDowngradeSeconds, time.Since(start).Seconds())
+		}
+
+		payload, err := chunk.NetworkEncode(to.air, c, to.oldFormat, to.pe)
+		if err != nil {
+			t.reportError(conn, pk, "encode level chunk", err)
+			t.metrics.Add(metrics.ChunkErrors, 1)
+			return
+		}
+		writeBuf.Write(payload)
+		pk.SubChunkCount = uint32(len(c.Sub()))
+	}
+	safeBytes := buf.Bytes()
+
+	countBorder, err := buf.ReadByte()
+	if err != nil {
+		pk.RawPayload = append(writeBuf.Bytes(), safeBytes...)
+		return
+	}
+	borderBytes := make([]byte, countBorder)
+	if _, err = buf.Read(borderBytes); err != nil {
+		pk.RawPayload = append(writeBuf.Bytes(), safeBytes...)
+		return
+	}
+	writeBuf.WriteByte(countBorder)
+	writeBuf.Write(borderBytes)
+
+	t.translateBlockActors(writeBuf, buf, upgrade)
+	pk.RawPayload = append(writeBuf.Bytes(), buf.Bytes()...)
+}
+
+// translateSubChunk translates the blocks and block actors of the SubChunk passed in place. If upgrade is true,
+// the sub chunks are upgraded from this version to the latest, and otherwise downgraded.
+func (t *DefaultBlockTranslator) translateSubChunk(conn *minecraft.Conn, pk *packet.SubChunk, upgrade bool) {
+	from, to := t.chunkFormats(upgrade)
+	r := world.Overworld.Range()
+	if t.oldFormat {
+		r = cube.Range{0, 255}
+	}
+	// Sub chunks sent to a client with the blob cache enabled are sent separately, in ClientCacheMissResponse.
+	cached := pk.CacheEnabled || !upgrade && conn.ClientCacheEnabled()
+
+	for i, entry := range pk.SubChunkEntries {
+		if entry.Result != protocol.SubChunkResultSuccess {
+			continue
+		}
+		buf := bytes.NewBuffer(entry.RawPayload)
+		writeBuf := bytes.NewBuffer(nil)
+		if !cached {
+			ind := byte(i)
+			subChunk, err := chunk.DecodeSubChunk(from.air, r, buf, &ind, chunk.NetworkEncoding, from.pse, from.pe)
+			if err != nil {
+				t.reportError(conn, pk, "decode sub chunk", err)
+				t.metrics.Add(metrics.ChunkErrors, 1)
+				continue
+			}
+			if upgrade {
+				t.UpgradeSubChunk(subChunk)
+			} else {
+				t.DowngradeSubChunk(subChunk)
+			}
+			writeBuf.Write(chunk.EncodeSubChunk(subChunk, chunk.NetworkEncoding, to.pe, chunk.SubChunkVersion9, r, int(ind)))
+		}
+
+		t.translateBlockActors(writeBuf, buf, upgrade)
+		entry.RawPayload = append(writeBuf.Bytes(), buf.Bytes()...)
+		pk.SubChunkEntries[i] = entry
+	}
+}
+
+// translateBlockActors reads the block actors from src until it holds no more of them, and writes them to dst
+// with their data translated. If upgrade is true, the data is upgraded from this version to the latest, and
+// otherwise downgraded.
+func (t *DefaultBlockTranslator) translateBlockActors(dst, src *bytes.Buffer, upgrade bool) {
+	enc := nbt.NewEncoderWithEncoding(dst, nbt.NetworkLittleEndian)
+	dec := nbt.NewDecoderWithEncoding(src, nbt.NetworkLittleEndian)
+	for {
+		var decNbt map[string]any
+		if err := dec.Decode(&decNbt); err != nil {
+			return
+		}
+		if upgrade {
+			t.mapping.UpgradeBlockActorData(decNbt)
+		} else {
+			t.mapping.DowngradeBlockActorData(decNbt)
+		}
+		if err := enc.Encode(decNbt); err != nil {
+			return
+		}
+	}
+}
+
 func (t *DefaultBlockTranslator) DowngradeBlockRuntimeID(input uint32) uint32 {
 	if t.latest == t.mapping {
 		return input
 	}
 	state, ok := t.latest.RuntimeIDToState(input)
 	if !ok {
+		t.metrics.Add(metrics.BlockFallbacks, 1)
 		return t.mapping.Air()
 	}
 	runtimeID, ok := t.mapping.StateToRuntimeID(state)
 	if !ok {
+		t.metrics.Add(metrics.BlockFallbacks, 1)
 		return t.mapping.Air()
 	}
 	return runtimeID
@@ -289,6 +516,26 @@ func (t *DefaultBlockTranslator) DowngradeSubChunk(input *chunk.SubChunk) {
 	}
 }
 
+// UpgradeChunk upgrades the blocks of a chunk of a server of this version to the latest blocks in place.
+func (t *DefaultBlockTranslator) UpgradeChunk(input *chunk.Chunk) {
+	if t.latest == t.mapping {
+		return
+	}
+	for _, sub := range input.Sub() {
+		t.UpgradeSubChunk(sub)
+	}
+}
+
+// UpgradeSubChunk upgrades the blocks of a sub chunk of a server of this version to the latest blocks in place.
+func (t *DefaultBlockTranslator) UpgradeSubChunk(input *chunk.SubChunk) {
+	if t.latest == t.mapping {
+		return
+	}
+	for _, storage := range input.Layers() {
+		storage.Palette().Replace(t.UpgradeBlockRuntimeID)
+	}
+}
+
 func (t *DefaultBlockTranslator) downgradeEntityMetadata(metadata map[uint32]any) map[uint32]any {
 	if t.latest == t.mapping {
 		return metadata
@@ -305,10 +552,12 @@ func (t *DefaultBlockTranslator) UpgradeBlockRuntimeID(input uint32) uint32 {
 	}
 	state, ok := t.mapping.RuntimeIDToState(input)
 	if !ok {
+		t.metrics.Add(metrics.BlockFallbacks, 1)
 		return t.latest.Air()
 	}
 	runtimeID, ok := t.latest.StateToRuntimeID(state)
 	if !ok {
+		t.metrics.Add(metrics.BlockFallbacks, 1)
 		return t.latest.Air()
 	}
 	return runtimeID
@@ -0,0 +1,137 @@
+package translator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/df-mc/worldupgrader/blockupgrader"
+	"github.com/oomph-ac/new-mv/internal/chunk"
+	"github.com/oomph-ac/new-mv/mapping"
+	"github.com/oomph-ac/new-mv/protocols/latest"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// legacyBlockVersion is the block version of the legacy block states used by the tests.
+const legacyBlockVersion int32 = (1 << 24) | (21 << 16) | (30 << 8)
+
+// newTestBlockTranslator returns a block translator between the block states of 1.21.30 and the latest block
+// states.
+func newTestBlockTranslator(t *testing.T) *DefaultBlockTranslator {
+	t.Helper()
+	raw, err := os.ReadFile("../protocols/v729/block_states.nbt")
+	if err != nil {
+		t.Fatalf("read legacy block states: %v", err)
+	}
+	legacy, err := mapping.NewBlockMapping(raw)
+	if err != nil {
+		t.Fatalf("load legacy block states: %v", err)
+	}
+	latestBlocks, err := latest.NewBlockMapping()
+	if err != nil {
+		t.Fatalf("load latest block states: %v", err)
+	}
+	return NewBlockTranslator(legacy, latestBlocks, chunk.NewNetworkPersistentEncoding(legacy, legacyBlockVersion), chunk.NewBlockPaletteEncoding(legacy, legacyBlockVersion), false)
+}
+
+// customState returns the state of the custom block with the name passed.
+func customState(name string) blockupgrader.BlockState {
+	return blockupgrader.BlockState{Name: name, Properties: map[string]any{}}
+}
+
+// sendCustomBlock sends a StartGame packet defining the custom block with the name passed over the connection.
+func sendCustomBlock(tr *DefaultBlockTranslator, conn *minecraft.Conn, name string) {
+	tr.DowngradeBlockPackets([]packet.Packet{&packet.StartGame{Blocks: []protocol.BlockEntry{{Name: name, Properties: map[string]any{}}}}}, conn)
+}
+
+func TestBlockTranslatorSessions(t *testing.T) {
+	tr := newTestBlockTranslator(t)
+
+	const conns = 16
+	var wg sync.WaitGroup
+	for i := 0; i < conns; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conn, name := new(minecraft.Conn), fmt.Sprintf("test:block_%d", i)
+			defer tr.Reset(conn)
+
+			sendCustomBlock(tr, conn, name)
+			legacy, latestMapping := tr.mappings(conn)
+			if _, ok := latestMapping.StateToRuntimeID(customState(name)); !ok {
+				t.Errorf("%v: custom state missing from latest mapping of connection", name)
+			}
+			if _, ok := legacy.StateToRuntimeID(customState(name)); !ok {
+				t.Errorf("%v: custom state missing from legacy mapping of connection", name)
+			}
+			other := fmt.Sprintf("test:block_%d", (i+1)%conns)
+			if _, ok := latestMapping.StateToRuntimeID(customState(other)); ok {
+				t.Errorf("%v: custom state of another connection in mapping of connection", name)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if _, ok := tr.latest.StateToRuntimeID(customState("test:block_0")); ok {
+		t.Fatalf("custom state of a connection added to the shared mapping")
+	}
+}
+
+func TestBlockTranslatorSessionEncodings(t *testing.T) {
+	tr := newTestBlockTranslator(t)
+	conn, name := new(minecraft.Conn), "test:block"
+	sendCustomBlock(tr, conn, name)
+
+	adjusted := tr.forConn(conn)
+	for _, c := range []struct {
+		name string
+		m    mapping.Block
+		pe   chunk.PaletteEncoding
+	}{
+		{name: "legacy", m: adjusted.mapping, pe: adjusted.pe},
+		{name: "latest", m: adjusted.latest, pe: adjusted.latestPe},
+	} {
+		rid, ok := c.m.StateToRuntimeID(customState(name))
+		if !ok {
+			t.Fatalf("%v: custom state missing from mapping of connection", c.name)
+		}
+		buf := bytes.NewBuffer(nil)
+		c.pe.Encode(buf, rid)
+		decoded, err := c.pe.Decode(buf)
+		if err != nil {
+			t.Fatalf("%v: decode custom state with palette encoding of connection: %v", c.name, err)
+		}
+		if decoded != rid {
+			t.Fatalf("%v: decoded runtime ID %v, expected %v", c.name, decoded, rid)
+		}
+	}
+}
+
+func TestItemTranslatorUsesBlockSession(t *testing.T) {
+	blocks := newTestBlockTranslator(t)
+	itemMapping, err := latest.NewItemMapping(false)
+	if err != nil {
+		t.Fatalf("load latest items: %v", err)
+	}
+	items := NewItemTranslator(itemMapping, itemMapping, blocks)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conn, name := new(minecraft.Conn), fmt.Sprintf("test:block_%d", i)
+			defer blocks.Reset(conn)
+
+			sendCustomBlock(blocks, conn, name)
+			if _, ok := items.forConn(conn).blockMappingLatest.StateToRuntimeID(customState(name)); !ok {
+				t.Errorf("%v: item translator does not use the block mappings of the connection", name)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
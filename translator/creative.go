@@ -0,0 +1,54 @@
+package translator
+
+import (
+	"fmt"
+
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// creativeKey uniquely identifies a downgraded creative item, so that latest items that collapse into the same
+// legacy item only show up once in the creative inventory.
+type creativeKey struct {
+	networkID      int32
+	metadata       uint32
+	blockRuntimeID int32
+	nbt            string
+}
+
+// downgradeCreativeContent downgrades the creative items in the CreativeContent packet passed. Items without a
+// legacy equivalent (that do not have a custom substitute registered) are dropped, and items that downgrade to
+// the same legacy item are deduplicated. The order of the server is kept, so that the first occurrence of an
+// item decides its position in the creative inventory. The remaining items are given consecutive network IDs,
+// which are mapped back to the IDs of the server in the session.
+func (t *DefaultItemTranslator) downgradeCreativeContent(pk *packet.CreativeContent, s *itemSession) {
+	items := make([]protocol.CreativeItem, 0, len(pk.Items))
+	seen := make(map[creativeKey]struct{}, len(pk.Items))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.creativeIDs = make(map[uint32]uint32, len(pk.Items))
+	for _, creativeItem := range pk.Items {
+		stack := t.DowngradeItemStack(creativeItem.Item)
+		if !t.representableOutput(stack) {
+			continue
+		}
+		key := creativeKey{networkID: stack.NetworkID, metadata: stack.MetadataValue, blockRuntimeID: stack.BlockRuntimeID}
+		if len(stack.NBTData) != 0 {
+			// fmt prints maps with sorted keys, so the resulting string is stable for equal NBT.
+			key.nbt = fmt.Sprint(stack.NBTData)
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		clientID := uint32(len(items) + 1)
+		s.creativeIDs[clientID] = creativeItem.CreativeItemNetworkID
+		items = append(items, protocol.CreativeItem{
+			CreativeItemNetworkID: clientID,
+			Item:                  stack,
+		})
+	}
+	pk.Items = items
+}
@@ -0,0 +1,78 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// creativeItem returns the creative item with the network ID passed holding the latest item with the name and
+// NBT passed.
+func creativeItem(t *testing.T, tr *DefaultItemTranslator, networkID uint32, name string, nbt map[string]any) protocol.CreativeItem {
+	stack := latestItem(t, tr, name)
+	stack.NBTData = nbt
+	return protocol.CreativeItem{CreativeItemNetworkID: networkID, Item: stack}
+}
+
+func TestDowngradeCreativeContent(t *testing.T) {
+	tr := newTestItemTranslator(t)
+	tests := []struct {
+		name  string
+		items []protocol.CreativeItem
+		// want holds the server network IDs of the items kept, in the order of the client network IDs 1...
+		want []uint32
+	}{
+		{
+			name:  "order of server kept",
+			items: []protocol.CreativeItem{creativeItem(t, tr, 7, "minecraft:stick", nil), creativeItem(t, tr, 3, "minecraft:diamond_sword", nil)},
+			want:  []uint32{7, 3},
+		},
+		{
+			name:  "item without legacy equivalent dropped",
+			items: []protocol.CreativeItem{creativeItem(t, tr, 1, "minecraft:mace", nil), creativeItem(t, tr, 2, "minecraft:stick", nil)},
+			want:  []uint32{2},
+		},
+		{
+			name:  "duplicate item dropped",
+			items: []protocol.CreativeItem{creativeItem(t, tr, 1, "minecraft:stick", nil), creativeItem(t, tr, 2, "minecraft:diamond_sword", nil), creativeItem(t, tr, 3, "minecraft:stick", nil)},
+			want:  []uint32{1, 2},
+		},
+		{
+			name: "items with different NBT kept",
+			items: []protocol.CreativeItem{
+				creativeItem(t, tr, 1, "minecraft:diamond_sword", map[string]any{"Damage": int32(1)}),
+				creativeItem(t, tr, 2, "minecraft:diamond_sword", map[string]any{"Damage": int32(2)}),
+				creativeItem(t, tr, 3, "minecraft:diamond_sword", map[string]any{"Damage": int32(1)}),
+			},
+			want: []uint32{1, 2},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := new(minecraft.Conn)
+			defer tr.Reset(conn)
+
+			pk := &packet.CreativeContent{Items: test.items}
+			tr.DowngradeItemPackets([]packet.Packet{pk}, conn)
+			if len(pk.Items) != len(test.want) {
+				t.Fatalf("kept %v creative items, expected %v", len(pk.Items), len(test.want))
+			}
+			for i, item := range pk.Items {
+				if item.CreativeItemNetworkID != uint32(i+1) {
+					t.Errorf("item %v has network ID %v, expected %v", i, item.CreativeItemNetworkID, i+1)
+				}
+			}
+
+			// Crafting every creative item the client was sent should request the item of the server.
+			for i, want := range test.want {
+				action := &protocol.CraftCreativeStackRequestAction{CreativeItemNetworkID: uint32(i + 1)}
+				tr.UpgradeItemPackets([]packet.Packet{&packet.ItemStackRequest{Requests: []protocol.ItemStackRequest{{Actions: []protocol.StackRequestAction{action}}}}}, conn)
+				if action.CreativeItemNetworkID != want {
+					t.Errorf("creative item %v maps to %v, expected %v", i+1, action.CreativeItemNetworkID, want)
+				}
+			}
+		})
+	}
+}
@@ -2,11 +2,15 @@ package translator
 
 import (
 	"fmt"
+	"maps"
 
 	"github.com/df-mc/dragonfly/server/world"
 	"github.com/oomph-ac/new-mv/internal/item"
+	"github.com/oomph-ac/new-mv/internal/session"
 	"github.com/oomph-ac/new-mv/mapping"
+	"github.com/oomph-ac/new-mv/metrics"
 	"github.com/oomph-ac/new-mv/packbuilder"
+	"github.com/oomph-ac/new-mv/trace"
 	"github.com/samber/lo"
 	"github.com/sandertv/gophertunnel/minecraft"
 	"github.com/sandertv/gophertunnel/minecraft/protocol"
@@ -36,8 +40,9 @@ type ItemTranslator interface {
 	// UpgradeItemDescriptorCount upgrades the input item descriptor (with count) to the latest item descriptor (with count).
 	UpgradeItemDescriptorCount(input protocol.ItemDescriptorCount) protocol.ItemDescriptorCount
 	UpgradeItemPackets(pks []packet.Packet, conn *minecraft.Conn) []packet.Packet
-	// Register registers a custom item entry.
-	Register(item world.CustomItem, replacement string)
+	// Register registers a custom item entry. An error is returned if the replacement does not exist or already
+	// has a custom item registered for it.
+	Register(item world.CustomItem, replacement string) error
 	// CustomItems lists all custom items used as substitutes, with the runtime id as the key
 	CustomItems() map[int32]world.CustomItem
 }
@@ -45,16 +50,76 @@ type ItemTranslator interface {
 type DefaultItemTranslator struct {
 	mapping            mapping.Item
 	latest             mapping.Item
+	blocks             *DefaultBlockTranslator
 	blockMapping       mapping.Block
 	blockMappingLatest mapping.Block
 	ridToCustomItem    map[int32]world.CustomItem
 	originalToCustom   map[int32]int32
 	customToOriginal   map[int32]int32
+	sessions           *session.Store[itemSession]
+
+	componentDowngrader func(map[string]any) map[string]any
+	tracer              *trace.Tracer
+	metrics             *metrics.Reporter
+}
+
+// NewItemTranslator returns a translator of the items of the item mappings passed. The block states of block
+// items are translated using the block mappings that the block translator passed uses for a connection.
+func NewItemTranslator(mapping mapping.Item, latestMapping mapping.Item, blocks *DefaultBlockTranslator) *DefaultItemTranslator {
+	return &DefaultItemTranslator{mapping: mapping, latest: latestMapping, blocks: blocks, blockMapping: blocks.mapping, blockMappingLatest: blocks.latest,
+		ridToCustomItem: make(map[int32]world.CustomItem), originalToCustom: make(map[int32]int32), customToOriginal: make(map[int32]int32),
+		sessions: session.NewStore(newItemSession)}
+}
+
+// WithComponentDowngrader sets the function used to downgrade the component data of the component-based items
+// sent by the server in the ItemComponent packet to the layout the legacy client expects.
+func (t *DefaultItemTranslator) WithComponentDowngrader(downgrader func(map[string]any) map[string]any) *DefaultItemTranslator {
+	t.componentDowngrader = downgrader
+	return t
+}
+
+// SetTracer sets the tracer that fallbacks and errors during translation are reported to.
+func (t *DefaultItemTranslator) SetTracer(tracer *trace.Tracer) {
+	t.tracer = tracer
+}
+
+// SetMetrics sets the reporter that measurements of translation are reported to.
+func (t *DefaultItemTranslator) SetMetrics(reporter *metrics.Reporter) {
+	t.metrics = reporter
+}
+
+// Fork returns a translator sharing the item mappings of t and the custom items registered to it, which
+// translates block items using the fork of the block translator of t passed. Custom items registered to the
+// fork, and items defined by the servers of its connections, are not visible to t or to other forks.
+func (t *DefaultItemTranslator) Fork(blocks *DefaultBlockTranslator) *DefaultItemTranslator {
+	fork := NewItemTranslator(t.mapping.Fork(), t.latest, blocks).WithComponentDowngrader(t.componentDowngrader)
+	fork.ridToCustomItem = maps.Clone(t.ridToCustomItem)
+	fork.originalToCustom = maps.Clone(t.originalToCustom)
+	fork.customToOriginal = maps.Clone(t.customToOriginal)
+	fork.tracer, fork.metrics = t.tracer, t.metrics
+	return fork
+}
+
+// Reset forgets everything known about the connection passed, such as the items and block states its server
+// defined and the item stacks sent to it.
+func (t *DefaultItemTranslator) Reset(conn *minecraft.Conn) {
+	t.sessions.Delete(conn)
 }
 
-func NewItemTranslator(mapping mapping.Item, latestMapping mapping.Item, blockMapping mapping.Block, blockMappingLatest mapping.Block) *DefaultItemTranslator {
-	return &DefaultItemTranslator{mapping: mapping, latest: latestMapping, blockMapping: blockMapping, blockMappingLatest: blockMappingLatest,
-		ridToCustomItem: make(map[int32]world.CustomItem), originalToCustom: make(map[int32]int32), customToOriginal: make(map[int32]int32)}
+// forConn returns the translator to use for the connection passed. It is a copy of t using the block mappings
+// the block translator uses for the connection and, if a StartGame packet was sent over the connection, the
+// item mappings holding the items it defined.
+func (t *DefaultItemTranslator) forConn(conn *minecraft.Conn) *DefaultItemTranslator {
+	adjusted := *t
+	adjusted.blockMapping, adjusted.blockMappingLatest = t.blocks.mappings(conn)
+
+	s := t.sessions.Get(conn)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.latest != nil {
+		adjusted.mapping, adjusted.latest = s.mapping, s.latest
+	}
+	return &adjusted
 }
 
 func (t *DefaultItemTranslator) DowngradeItemType(input protocol.ItemType) protocol.ItemType {
@@ -85,6 +150,7 @@ func (t *DefaultItemTranslator) DowngradeItemType(input protocol.ItemType) proto
 
 		networkID, ok = t.mapping.ItemNameToRuntimeID(i.Name)
 		if !ok {
+			t.metrics.Add(metrics.ItemFallbacks, 1)
 			networkID, _ = t.mapping.ItemNameToRuntimeID("minecraft:info_update")
 		}
 	}
@@ -170,7 +236,8 @@ func (t *DefaultItemTranslator) DowngradeItemDescriptor(input protocol.ItemDescr
 		}
 		return descriptor
 	}
-	panic("unknown item descriptor")
+	// Descriptors of unknown types hold no item to translate and are passed on as they are.
+	return input
 }
 
 func (t *DefaultItemTranslator) DowngradeItemDescriptorCount(input protocol.ItemDescriptorCount) protocol.ItemDescriptorCount {
@@ -203,6 +270,7 @@ func (t *DefaultItemTranslator) UpgradeItemType(input protocol.ItemType) protoco
 		}, t.latest.ItemVersion())
 		networkID, ok = t.latest.ItemNameToRuntimeID(i.Name)
 		if !ok {
+			t.metrics.Add(metrics.ItemFallbacks, 1)
 			networkID, _ = t.latest.ItemNameToRuntimeID("minecraft:info_update")
 		}
 	}
@@ -285,7 +353,8 @@ func (t *DefaultItemTranslator) UpgradeItemDescriptor(input protocol.ItemDescrip
 		}
 		return descriptor
 	}
-	panic("unknown item descriptor")
+	// Descriptors of unknown types hold no item to translate and are passed on as they are.
+	return input
 }
 
 func (t *DefaultItemTranslator) UpgradeItemDescriptorCount(input protocol.ItemDescriptorCount) protocol.ItemDescriptorCount {
@@ -296,7 +365,8 @@ func (t *DefaultItemTranslator) UpgradeItemDescriptorCount(input protocol.ItemDe
 	return input
 }
 
-func (t *DefaultItemTranslator) DowngradeItemPackets(pks []packet.Packet, _ *minecraft.Conn) (result []packet.Packet) {
+func (t *DefaultItemTranslator) DowngradeItemPackets(pks []packet.Packet, conn *minecraft.Conn) (result []packet.Packet) {
+	t = t.forConn(conn)
 	for _, pk := range pks {
 		switch pk := pk.(type) {
 		case *packet.MobEquipment:
@@ -306,6 +376,7 @@ func (t *DefaultItemTranslator) DowngradeItemPackets(pks []packet.Packet, _ *min
 			pk.Chestplate = t.DowngradeItemInstance(pk.Chestplate)
 			pk.Leggings = t.DowngradeItemInstance(pk.Leggings)
 			pk.Boots = t.DowngradeItemInstance(pk.Boots)
+			pk.Body = t.DowngradeItemInstance(pk.Body)
 		case *packet.ActorEvent:
 			if pk.EventType == packet.ActorEventFeed {
 				value := pk.EventData
@@ -317,11 +388,15 @@ func (t *DefaultItemTranslator) DowngradeItemPackets(pks []packet.Packet, _ *min
 		case *packet.AddPlayer:
 			pk.HeldItem = t.DowngradeItemInstance(pk.HeldItem)
 		case *packet.InventorySlot:
-			pk.NewItem = t.DowngradeItemInstance(pk.NewItem)
+			pk.NewItem = t.downgradeTrackedInstance(pk.WindowID, pk.Slot, pk.NewItem, t.sessions.Get(conn))
 		case *packet.InventoryContent:
-			pk.Content = lo.Map(pk.Content, func(item protocol.ItemInstance, _ int) protocol.ItemInstance {
-				return t.DowngradeItemInstance(item)
-			})
+			s := t.sessions.Get(conn)
+			s.clearWindow(pk.WindowID)
+			for i, instance := range pk.Content {
+				pk.Content[i] = t.downgradeTrackedInstance(pk.WindowID, uint32(i), instance, s)
+			}
+		case *packet.ItemStackResponse:
+			t.sessions.Get(conn).trackStackResponse(pk)
 		case *packet.ItemStackRequest:
 			for i, request := range pk.Requests {
 				for i2, action := range request.Actions {
@@ -334,94 +409,10 @@ func (t *DefaultItemTranslator) DowngradeItemPackets(pks []packet.Packet, _ *min
 				}
 			}
 		case *packet.CraftingData:
-			for i, recipe := range pk.Recipes {
-				switch recipe := recipe.(type) {
-				case *protocol.ShapelessRecipe:
-					for i2, input := range recipe.Input {
-						recipe.Input[i2] = t.DowngradeItemDescriptorCount(input)
-					}
-					for i2, stack := range recipe.Output {
-						recipe.Output[i2] = t.DowngradeItemStack(stack)
-					}
-					pk.Recipes[i] = recipe
-				case *protocol.ShapedRecipe:
-					for i2, input := range recipe.Input {
-						recipe.Input[i2] = t.DowngradeItemDescriptorCount(input)
-					}
-					for i2, stack := range recipe.Output {
-						recipe.Output[i2] = t.DowngradeItemStack(stack)
-					}
-					pk.Recipes[i] = recipe
-				case *protocol.FurnaceRecipe:
-					recipe.InputType = t.DowngradeItemType(recipe.InputType)
-					recipe.Output = t.DowngradeItemStack(recipe.Output)
-					pk.Recipes[i] = recipe
-				case *protocol.FurnaceDataRecipe:
-					recipe.InputType = t.DowngradeItemType(recipe.InputType)
-					recipe.Output = t.DowngradeItemStack(recipe.Output)
-					pk.Recipes[i] = recipe
-				case *protocol.ShulkerBoxRecipe:
-					for i2, input := range recipe.Input {
-						recipe.Input[i2] = t.DowngradeItemDescriptorCount(input)
-					}
-					for i2, stack := range recipe.Output {
-						recipe.Output[i2] = t.DowngradeItemStack(stack)
-					}
-					pk.Recipes[i] = recipe
-				case *protocol.ShapelessChemistryRecipe:
-					for i2, input := range recipe.Input {
-						recipe.Input[i2] = t.DowngradeItemDescriptorCount(input)
-					}
-					for i2, stack := range recipe.Output {
-						recipe.Output[i2] = t.DowngradeItemStack(stack)
-					}
-					pk.Recipes[i] = recipe
-				case *protocol.ShapedChemistryRecipe:
-					for i2, input := range recipe.Input {
-						recipe.Input[i2] = t.DowngradeItemDescriptorCount(input)
-					}
-					for i2, stack := range recipe.Output {
-						recipe.Output[i2] = t.DowngradeItemStack(stack)
-					}
-					pk.Recipes[i] = recipe
-				case *protocol.SmithingTransformRecipe:
-					recipe.Template = t.DowngradeItemDescriptorCount(recipe.Template)
-					recipe.Base = t.DowngradeItemDescriptorCount(recipe.Base)
-					recipe.Addition = t.DowngradeItemDescriptorCount(recipe.Addition)
-					recipe.Result = t.DowngradeItemStack(recipe.Result)
-					pk.Recipes[i] = recipe
-				case *protocol.SmithingTrimRecipe:
-					recipe.Template = t.DowngradeItemDescriptorCount(recipe.Template)
-					recipe.Base = t.DowngradeItemDescriptorCount(recipe.Base)
-					recipe.Addition = t.DowngradeItemDescriptorCount(recipe.Addition)
-				}
-			}
-			for i, recipe := range pk.PotionRecipes {
-				itemType := t.DowngradeItemType(protocol.ItemType{NetworkID: recipe.InputPotionID, MetadataValue: uint32(recipe.InputPotionMetadata)})
-				recipe.InputPotionID, recipe.InputPotionMetadata = itemType.NetworkID, int32(itemType.MetadataValue)
-				itemType = t.DowngradeItemType(protocol.ItemType{NetworkID: recipe.ReagentItemID, MetadataValue: uint32(recipe.ReagentItemMetadata)})
-				recipe.ReagentItemID, recipe.ReagentItemMetadata = itemType.NetworkID, int32(itemType.MetadataValue)
-				itemType = t.DowngradeItemType(protocol.ItemType{NetworkID: recipe.OutputPotionID, MetadataValue: uint32(recipe.OutputPotionMetadata)})
-				recipe.OutputPotionID, recipe.OutputPotionMetadata = itemType.NetworkID, int32(itemType.MetadataValue)
-				pk.PotionRecipes[i] = recipe
-			}
-			for i, recipe := range pk.PotionContainerChangeRecipes {
-				itemType := t.DowngradeItemType(protocol.ItemType{NetworkID: recipe.InputItemID})
-				recipe.InputItemID = itemType.NetworkID
-				itemType = t.DowngradeItemType(protocol.ItemType{NetworkID: recipe.ReagentItemID})
-				recipe.ReagentItemID = itemType.NetworkID
-				itemType = t.DowngradeItemType(protocol.ItemType{NetworkID: recipe.OutputItemID})
-				recipe.OutputItemID = itemType.NetworkID
-				pk.PotionContainerChangeRecipes[i] = recipe
-			}
-			for i, recipe := range pk.MaterialReducers {
-				recipe.InputItem = t.DowngradeItemType(recipe.InputItem)
-				for i2, output := range recipe.Outputs {
-					itemType := t.DowngradeItemType(protocol.ItemType{NetworkID: output.NetworkID})
-					output.NetworkID = itemType.NetworkID
-					recipe.Outputs[i2] = output
-				}
-				pk.MaterialReducers[i] = recipe
+			count := len(pk.Recipes)
+			t.downgradeCraftingData(pk, t.sessions.Get(conn))
+			if dropped := count - len(pk.Recipes); dropped > 0 {
+				t.tracer.Fallback(conn, "dropped recipes without legacy equivalent", "count", dropped)
 			}
 		//case *packet.CraftingEvent:
 		//	pk.Input = lo.Map(pk.Input, func(item protocol.ItemInstance, _ int) protocol.ItemInstance {
@@ -446,10 +437,10 @@ func (t *DefaultItemTranslator) DowngradeItemPackets(pks []packet.Packet, _ *min
 			}
 			pk.ItemInteractionData.HeldItem = t.DowngradeItemInstance(pk.ItemInteractionData.HeldItem)
 		case *packet.CreativeContent:
-			for i, creativeItem := range pk.Items {
-				creativeItem.Item = t.DowngradeItemStack(creativeItem.Item)
-
-				pk.Items[i] = creativeItem
+			count := len(pk.Items)
+			t.downgradeCreativeContent(pk, t.sessions.Get(conn))
+			if dropped := count - len(pk.Items); dropped > 0 {
+				t.tracer.Fallback(conn, "dropped duplicate creative items or items without legacy equivalent", "count", dropped)
 			}
 		case *packet.InventoryTransaction:
 			for i, action := range pk.Actions {
@@ -479,37 +470,24 @@ func (t *DefaultItemTranslator) DowngradeItemPackets(pks []packet.Packet, _ *min
 				pk.EventData = (itemType.NetworkID << 16) | int32(itemType.MetadataValue)
 			}
 		case *packet.StartGame:
-			for i, entry := range pk.Items {
-				if !entry.ComponentBased {
-					itemType := t.DowngradeItemType(protocol.ItemType{
-						NetworkID:     int32(entry.RuntimeID),
-						MetadataValue: 0,
-					})
-					if itemType.NetworkID == t.mapping.Air() {
-						removeIndex(pk.Items, i)
-						continue
-					}
-					entry.RuntimeID = int16(itemType.NetworkID)
+			registry, latest, legacy := t.downgradeItemRegistry(pk.Items)
+			pk.Items = registry.Entries
+			if len(registry.Dropped) > 0 {
+				t.tracer.Fallback(conn, "dropped items without legacy equivalent", "count", len(registry.Dropped))
+			}
 
-					var ok bool
-					if entry.Name, ok = t.mapping.ItemRuntimeIDToName(itemType.NetworkID); !ok {
-						panic(itemType)
-					}
-				} else {
-					t.latest.RegisterEntry(entry.Name)
-					entry.RuntimeID = int16(t.mapping.RegisterEntry(entry.Name))
+			s := t.sessions.Get(conn)
+			s.mu.Lock()
+			s.registry, s.latest, s.mapping = registry, latest, legacy
+			s.mu.Unlock()
+			t = t.forConn(conn)
+		case *packet.ItemComponent:
+			if t.componentDowngrader != nil {
+				for i, entry := range pk.Items {
+					entry.Data = t.componentDowngrader(entry.Data)
+					pk.Items[i] = entry
 				}
-				pk.Items[i] = entry
-			}
-			for rid, i := range t.CustomItems() {
-				name, _ := i.EncodeItem()
-				pk.Items = append(pk.Items, protocol.ItemEntry{
-					Name:           name,
-					RuntimeID:      int16(rid),
-					ComponentBased: true,
-				})
 			}
-		case *packet.ItemComponent:
 			for _, i := range t.CustomItems() {
 				name, _ := i.EncodeItem()
 				pk.Items = append(pk.Items, protocol.ItemComponentEntry{
@@ -523,16 +501,18 @@ func (t *DefaultItemTranslator) DowngradeItemPackets(pks []packet.Packet, _ *min
 	return result
 }
 
-func (t *DefaultItemTranslator) UpgradeItemPackets(pks []packet.Packet, _ *minecraft.Conn) (result []packet.Packet) {
+func (t *DefaultItemTranslator) UpgradeItemPackets(pks []packet.Packet, conn *minecraft.Conn) (result []packet.Packet) {
+	t = t.forConn(conn)
 	for _, pk := range pks {
 		switch pk := pk.(type) {
 		case *packet.MobEquipment:
-			pk.NewItem = t.UpgradeItemInstance(pk.NewItem)
+			pk.NewItem = t.upgradeTrackedInstance(pk.NewItem, t.sessions.Get(conn))
 		case *packet.MobArmourEquipment:
 			pk.Helmet = t.UpgradeItemInstance(pk.Helmet)
 			pk.Chestplate = t.UpgradeItemInstance(pk.Chestplate)
 			pk.Leggings = t.UpgradeItemInstance(pk.Leggings)
 			pk.Boots = t.UpgradeItemInstance(pk.Boots)
+			pk.Body = t.UpgradeItemInstance(pk.Body)
 		case *packet.AddItemActor:
 			pk.Item = t.UpgradeItemInstance(pk.Item)
 		case *packet.AddPlayer:
@@ -544,11 +524,14 @@ func (t *DefaultItemTranslator) UpgradeItemPackets(pks []packet.Packet, _ *minec
 				return t.UpgradeItemInstance(item)
 			})
 		case *packet.ItemStackRequest:
+			s := t.sessions.Get(conn)
 			for i, request := range pk.Requests {
+				s.upgradeStackRequestNetworkIDs(request.Actions)
+				s.trackStackRequest(request)
 				for i2, action := range request.Actions {
 					if act, ok := action.(*protocol.CraftResultsDeprecatedStackRequestAction); ok {
 						act.ResultItems = lo.Map(act.ResultItems, func(item protocol.ItemStack, _ int) protocol.ItemStack {
-							return t.UpgradeItemStack(item)
+							return t.upgradeTrackedStack(item, s)
 						})
 						pk.Requests[i].Actions[i2] = act
 					}
@@ -652,43 +635,47 @@ func (t *DefaultItemTranslator) UpgradeItemPackets(pks []packet.Packet, _ *minec
 		//		return t.UpgradeItemInstance(item)
 		//	})
 		case *packet.PlayerAuthInput:
+			s := t.sessions.Get(conn)
+			s.upgradeStackRequestNetworkIDs(pk.ItemStackRequest.Actions)
+			s.trackStackRequest(pk.ItemStackRequest)
 			for i, action := range pk.ItemStackRequest.Actions {
 				if act, ok := action.(*protocol.CraftResultsDeprecatedStackRequestAction); ok {
 					act.ResultItems = lo.Map(act.ResultItems, func(item protocol.ItemStack, _ int) protocol.ItemStack {
-						return t.UpgradeItemStack(item)
+						return t.upgradeTrackedStack(item, s)
 					})
 					pk.ItemStackRequest.Actions[i] = act
 				}
 			}
 			for i, action := range pk.ItemInteractionData.Actions {
-				action.OldItem = t.UpgradeItemInstance(action.OldItem)
-				action.NewItem = t.UpgradeItemInstance(action.NewItem)
+				action.OldItem = t.upgradeTrackedInstance(action.OldItem, s)
+				action.NewItem = t.upgradeTrackedInstance(action.NewItem, s)
 				pk.ItemInteractionData.Actions[i] = action
 			}
-			pk.ItemInteractionData.HeldItem = t.UpgradeItemInstance(pk.ItemInteractionData.HeldItem)
+			pk.ItemInteractionData.HeldItem = t.upgradeTrackedInstance(pk.ItemInteractionData.HeldItem, s)
 		case *packet.CreativeContent:
 			for i, creativeItem := range pk.Items {
 				creativeItem.Item = t.UpgradeItemStack(creativeItem.Item)
 				pk.Items[i] = creativeItem
 			}
 		case *packet.InventoryTransaction:
+			s := t.sessions.Get(conn)
 			for i, action := range pk.Actions {
-				action.OldItem = t.UpgradeItemInstance(action.OldItem)
-				action.NewItem = t.UpgradeItemInstance(action.NewItem)
+				action.OldItem = t.upgradeTrackedInstance(action.OldItem, s)
+				action.NewItem = t.upgradeTrackedInstance(action.NewItem, s)
 				pk.Actions[i] = action
 			}
 			switch transactionData := pk.TransactionData.(type) {
 			case *protocol.UseItemTransactionData:
-				transactionData.HeldItem = t.UpgradeItemInstance(transactionData.HeldItem)
+				transactionData.HeldItem = t.upgradeTrackedInstance(transactionData.HeldItem, s)
 				for i, action := range transactionData.Actions {
-					action.OldItem = t.UpgradeItemInstance(action.OldItem)
-					action.NewItem = t.UpgradeItemInstance(action.NewItem)
+					action.OldItem = t.upgradeTrackedInstance(action.OldItem, s)
+					action.NewItem = t.upgradeTrackedInstance(action.NewItem, s)
 					transactionData.Actions[i] = action
 				}
 			case *protocol.UseItemOnEntityTransactionData:
-				transactionData.HeldItem = t.UpgradeItemInstance(transactionData.HeldItem)
+				transactionData.HeldItem = t.upgradeTrackedInstance(transactionData.HeldItem, s)
 			case *protocol.ReleaseItemTransactionData:
-				transactionData.HeldItem = t.UpgradeItemInstance(transactionData.HeldItem)
+				transactionData.HeldItem = t.upgradeTrackedInstance(transactionData.HeldItem, s)
 			}
 		case *packet.LevelEvent:
 			if pk.EventType == packet.LevelEventParticleLegacyEvent|14 { // egg crack
@@ -699,32 +686,14 @@ func (t *DefaultItemTranslator) UpgradeItemPackets(pks []packet.Packet, _ *minec
 				pk.EventData = (itemType.NetworkID << 16) | int32(itemType.MetadataValue)
 			}
 		case *packet.StartGame:
-			for i, entry := range pk.Items {
-				if !entry.ComponentBased {
-					itemType := t.UpgradeItemType(protocol.ItemType{
-						NetworkID:     int32(entry.RuntimeID),
-						MetadataValue: 0,
-					})
-					entry.RuntimeID = int16(itemType.NetworkID)
-
-					var ok bool
-					if entry.Name, ok = t.latest.ItemRuntimeIDToName(itemType.NetworkID); !ok {
-						panic(itemType)
-					}
-				} else {
-					t.latest.RegisterEntry(entry.Name)
-					entry.RuntimeID = int16(t.mapping.RegisterEntry(entry.Name))
-				}
-				pk.Items[i] = entry
-			}
-			for rid, i := range t.CustomItems() {
-				name, _ := i.EncodeItem()
-				pk.Items = append(pk.Items, protocol.ItemEntry{
-					Name:           name,
-					RuntimeID:      int16(rid),
-					ComponentBased: true,
-				})
-			}
+			registry, latest, legacy := t.upgradeItemRegistry(pk.Items)
+			pk.Items = registry.Entries
+
+			s := t.sessions.Get(conn)
+			s.mu.Lock()
+			s.registry, s.latest, s.mapping = registry, latest, legacy
+			s.mu.Unlock()
+			t = t.forConn(conn)
 		case *packet.ItemComponent:
 			for _, i := range t.CustomItems() {
 				name, _ := i.EncodeItem()
@@ -739,28 +708,23 @@ func (t *DefaultItemTranslator) UpgradeItemPackets(pks []packet.Packet, _ *minec
 	return result
 }
 
-func (t *DefaultItemTranslator) Register(item world.CustomItem, replacement string) {
+func (t *DefaultItemTranslator) Register(item world.CustomItem, replacement string) error {
 	name, _ := item.EncodeItem()
 	originalRid, ok := t.latest.ItemNameToRuntimeID(replacement)
 	if !ok {
-		panic(fmt.Errorf("%v not found in latest items", replacement))
+		return fmt.Errorf("%v not found in latest items", replacement)
 	}
 	if _, ok := t.originalToCustom[originalRid]; ok {
-		panic(fmt.Errorf("%v is already mapped", replacement))
+		return fmt.Errorf("%v is already mapped", replacement)
 	}
 
 	nextRID := t.mapping.RegisterEntry(name)
 	t.ridToCustomItem[nextRID] = item
 	t.originalToCustom[originalRid] = nextRID
 	t.customToOriginal[nextRID] = originalRid
+	return nil
 }
 
 func (t *DefaultItemTranslator) CustomItems() map[int32]world.CustomItem {
 	return t.ridToCustomItem
 }
-
-func removeIndex[T any](s []T, index int) []T {
-	ret := make([]T, 0)
-	ret = append(ret, s[:index]...)
-	return append(ret, s[index+1:]...)
-}
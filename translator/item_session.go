@@ -0,0 +1,72 @@
+package translator
+
+import (
+	"sync"
+
+	"github.com/oomph-ac/new-mv/mapping"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// itemSession holds the item related state of a single connection that is needed to translate packets sent by
+// the client back to what the server sent.
+type itemSession struct {
+	mu sync.Mutex
+	// recipeIDs maps the recipe network IDs sent to the client to the recipe network IDs used by the server.
+	recipeIDs map[uint32]uint32
+	// creativeIDs maps the creative item network IDs sent to the client to the creative item network IDs used
+	// by the server.
+	creativeIDs map[uint32]uint32
+	// stacks holds the item stack held by every stack network ID sent to the client in an inventory.
+	stacks map[int32]trackedStack
+	// windowStacks holds the stack network ID in every slot of every window.
+	windowStacks map[uint32]map[uint32]int32
+	// pendingMoves holds, for every item stack request of the client that has not yet been responded to, the
+	// stack network ID that the request moved into every slot it changed.
+	pendingMoves map[int32]map[stackSlot]int32
+	// registry is the item table last built from a StartGame packet.
+	registry ItemRegistry
+	// mapping and latest are the item mappings holding the items defined in the last StartGame packet, or nil if
+	// no StartGame packet was sent yet.
+	mapping, latest mapping.Item
+}
+
+// newItemSession returns a new, empty itemSession.
+func newItemSession() *itemSession {
+	return &itemSession{
+		recipeIDs:    make(map[uint32]uint32),
+		creativeIDs:  make(map[uint32]uint32),
+		stacks:       make(map[int32]trackedStack),
+		windowStacks: make(map[uint32]map[uint32]int32),
+		pendingMoves: make(map[int32]map[stackSlot]int32),
+	}
+}
+
+// upgradeStackRequestNetworkIDs maps the recipe and creative item network IDs in the stack request actions
+// passed, which are the IDs the legacy client was sent, back to the network IDs used by the server.
+func (s *itemSession) upgradeStackRequestNetworkIDs(actions []protocol.StackRequestAction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, action := range actions {
+		var (
+			networkID *uint32
+			ids       = s.recipeIDs
+		)
+		switch action := action.(type) {
+		case *protocol.CraftRecipeStackRequestAction:
+			networkID = &action.RecipeNetworkID
+		case *protocol.AutoCraftRecipeStackRequestAction:
+			networkID = &action.RecipeNetworkID
+		case *protocol.CraftRecipeOptionalStackRequestAction:
+			networkID = &action.RecipeNetworkID
+		case *protocol.CraftGrindstoneRecipeStackRequestAction:
+			networkID = &action.RecipeNetworkID
+		case *protocol.CraftCreativeStackRequestAction:
+			networkID, ids = &action.CreativeItemNetworkID, s.creativeIDs
+		default:
+			continue
+		}
+		if serverID, ok := ids[*networkID]; ok {
+			*networkID = serverID
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package translator
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// downgradeCraftingData downgrades all recipes in the CraftingData packet passed. Recipes that the legacy client
+// cannot represent, because one of their inputs or outputs has no legacy equivalent, are dropped. The remaining
+// recipes are given consecutive network IDs, which are mapped back to the IDs of the server in the session.
+func (t *DefaultItemTranslator) downgradeCraftingData(pk *packet.CraftingData, s *itemSession) {
+	recipes := make([]protocol.Recipe, 0, len(pk.Recipes))
+
+	s.mu.Lock()
+	if pk.ClearRecipes {
+		s.recipeIDs = make(map[uint32]uint32)
+	}
+	for _, recipe := range pk.Recipes {
+		if !t.downgradeRecipe(recipe) {
+			continue
+		}
+		if networkID := recipeNetworkID(recipe); networkID != nil {
+			clientID := uint32(len(s.recipeIDs) + 1)
+			s.recipeIDs[clientID] = *networkID
+			*networkID = clientID
+		}
+		recipes = append(recipes, recipe)
+	}
+	s.mu.Unlock()
+	pk.Recipes = recipes
+
+	potionRecipes := make([]protocol.PotionRecipe, 0, len(pk.PotionRecipes))
+	for _, recipe := range pk.PotionRecipes {
+		itemType := t.DowngradeItemType(protocol.ItemType{NetworkID: recipe.InputPotionID, MetadataValue: uint32(recipe.InputPotionMetadata)})
+		recipe.InputPotionID, recipe.InputPotionMetadata = itemType.NetworkID, int32(itemType.MetadataValue)
+		itemType = t.DowngradeItemType(protocol.ItemType{NetworkID: recipe.ReagentItemID, MetadataValue: uint32(recipe.ReagentItemMetadata)})
+		recipe.ReagentItemID, recipe.ReagentItemMetadata = itemType.NetworkID, int32(itemType.MetadataValue)
+		itemType = t.DowngradeItemType(protocol.ItemType{NetworkID: recipe.OutputPotionID, MetadataValue: uint32(recipe.OutputPotionMetadata)})
+		recipe.OutputPotionID, recipe.OutputPotionMetadata = itemType.NetworkID, int32(itemType.MetadataValue)
+		if !t.representable(recipe.InputPotionID) || !t.representable(recipe.ReagentItemID) || !t.representable(recipe.OutputPotionID) {
+			continue
+		}
+		potionRecipes = append(potionRecipes, recipe)
+	}
+	pk.PotionRecipes = potionRecipes
+
+	containerRecipes := make([]protocol.PotionContainerChangeRecipe, 0, len(pk.PotionContainerChangeRecipes))
+	for _, recipe := range pk.PotionContainerChangeRecipes {
+		recipe.InputItemID = t.DowngradeItemType(protocol.ItemType{NetworkID: recipe.InputItemID}).NetworkID
+		recipe.ReagentItemID = t.DowngradeItemType(protocol.ItemType{NetworkID: recipe.ReagentItemID}).NetworkID
+		recipe.OutputItemID = t.DowngradeItemType(protocol.ItemType{NetworkID: recipe.OutputItemID}).NetworkID
+		if !t.representable(recipe.InputItemID) || !t.representable(recipe.ReagentItemID) || !t.representable(recipe.OutputItemID) {
+			continue
+		}
+		containerRecipes = append(containerRecipes, recipe)
+	}
+	pk.PotionContainerChangeRecipes = containerRecipes
+
+	for i, recipe := range pk.MaterialReducers {
+		recipe.InputItem = t.DowngradeItemType(recipe.InputItem)
+		for i2, output := range recipe.Outputs {
+			itemType := t.DowngradeItemType(protocol.ItemType{NetworkID: output.NetworkID})
+			output.NetworkID = itemType.NetworkID
+			recipe.Outputs[i2] = output
+		}
+		pk.MaterialReducers[i] = recipe
+	}
+}
+
+// downgradeRecipe downgrades all items in the recipe passed in place. It returns false if the recipe ended up
+// referencing an item that the legacy client cannot represent.
+func (t *DefaultItemTranslator) downgradeRecipe(recipe protocol.Recipe) bool {
+	switch recipe := recipe.(type) {
+	case *protocol.ShapelessRecipe:
+		return t.downgradeRecipeItems(recipe.Input, recipe.Output)
+	case *protocol.ShapedRecipe:
+		return t.downgradeRecipeItems(recipe.Input, recipe.Output)
+	case *protocol.ShulkerBoxRecipe:
+		return t.downgradeRecipeItems(recipe.Input, recipe.Output)
+	case *protocol.ShapelessChemistryRecipe:
+		return t.downgradeRecipeItems(recipe.Input, recipe.Output)
+	case *protocol.ShapedChemistryRecipe:
+		return t.downgradeRecipeItems(recipe.Input, recipe.Output)
+	case *protocol.FurnaceRecipe:
+		recipe.InputType = t.DowngradeItemType(recipe.InputType)
+		recipe.Output = t.DowngradeItemStack(recipe.Output)
+		return t.representable(recipe.InputType.NetworkID) && t.representableOutput(recipe.Output)
+	case *protocol.FurnaceDataRecipe:
+		recipe.InputType = t.DowngradeItemType(recipe.InputType)
+		recipe.Output = t.DowngradeItemStack(recipe.Output)
+		return t.representable(recipe.InputType.NetworkID) && t.representableOutput(recipe.Output)
+	case *protocol.SmithingTransformRecipe:
+		valid := t.downgradeDescriptors(&recipe.Template, &recipe.Base, &recipe.Addition)
+		recipe.Result = t.DowngradeItemStack(recipe.Result)
+		return valid && t.representableOutput(recipe.Result)
+	case *protocol.SmithingTrimRecipe:
+		return t.downgradeDescriptors(&recipe.Template, &recipe.Base, &recipe.Addition)
+	}
+	return true
+}
+
+// downgradeRecipeItems downgrades the input descriptors and output stacks passed in place and reports if all of
+// them are representable by the legacy client.
+func (t *DefaultItemTranslator) downgradeRecipeItems(input []protocol.ItemDescriptorCount, output []protocol.ItemStack) bool {
+	valid := true
+	for i := range input {
+		if !t.downgradeDescriptors(&input[i]) {
+			valid = false
+		}
+	}
+	for i, stack := range output {
+		output[i] = t.DowngradeItemStack(stack)
+		if !t.representableOutput(output[i]) {
+			valid = false
+		}
+	}
+	return valid
+}
+
+// downgradeDescriptors downgrades the item descriptors passed in place and reports if all of them are
+// representable by the legacy client.
+func (t *DefaultItemTranslator) downgradeDescriptors(descriptors ...*protocol.ItemDescriptorCount) bool {
+	valid := true
+	for _, descriptor := range descriptors {
+		*descriptor = t.DowngradeItemDescriptorCount(*descriptor)
+		if !t.representableDescriptor(descriptor.Descriptor) {
+			valid = false
+		}
+	}
+	return valid
+}
+
+// representable reports if the legacy network ID passed refers to an item that exists for the legacy client,
+// as opposed to the placeholder used for items without a legacy equivalent.
+func (t *DefaultItemTranslator) representable(networkID int32) bool {
+	placeholder, ok := t.mapping.ItemNameToRuntimeID("minecraft:info_update")
+	return !ok || networkID != placeholder
+}
+
+// representableOutput reports if the downgraded recipe output passed is an item the legacy client can craft.
+func (t *DefaultItemTranslator) representableOutput(stack protocol.ItemStack) bool {
+	return stack.NetworkID != t.mapping.Air() && t.representable(stack.NetworkID)
+}
+
+// representableDescriptor reports if the downgraded item descriptor passed matches an item that exists for the
+// legacy client.
+func (t *DefaultItemTranslator) representableDescriptor(descriptor protocol.ItemDescriptor) bool {
+	switch descriptor := descriptor.(type) {
+	case *protocol.DefaultItemDescriptor:
+		return t.representable(int32(descriptor.NetworkID))
+	case *protocol.DeferredItemDescriptor:
+		return descriptor.Name != "minecraft:air" && descriptor.Name != "minecraft:info_update"
+	case *protocol.ComplexAliasItemDescriptor:
+		return descriptor.Name != "minecraft:air" && descriptor.Name != "minecraft:info_update"
+	}
+	return true
+}
+
+// recipeNetworkID returns a pointer to the network ID of the recipe passed, or nil if the recipe does not have
+// one.
+func recipeNetworkID(recipe protocol.Recipe) *uint32 {
+	switch recipe := recipe.(type) {
+	case *protocol.ShapelessRecipe:
+		return &recipe.RecipeNetworkID
+	case *protocol.ShapedRecipe:
+		return &recipe.RecipeNetworkID
+	case *protocol.ShulkerBoxRecipe:
+		return &recipe.RecipeNetworkID
+	case *protocol.ShapelessChemistryRecipe:
+		return &recipe.RecipeNetworkID
+	case *protocol.ShapedChemistryRecipe:
+		return &recipe.RecipeNetworkID
+	case *protocol.MultiRecipe:
+		return &recipe.RecipeNetworkID
+	case *protocol.SmithingTransformRecipe:
+		return &recipe.RecipeNetworkID
+	case *protocol.SmithingTrimRecipe:
+		return &recipe.RecipeNetworkID
+	}
+	return nil
+}
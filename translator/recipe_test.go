@@ -0,0 +1,152 @@
+package translator
+
+import (
+	"os"
+	"testing"
+
+	"github.com/oomph-ac/new-mv/mapping"
+	"github.com/oomph-ac/new-mv/protocols/latest"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// legacyItemVersion is the item version of the legacy items used by the tests.
+const legacyItemVersion = 161
+
+// newTestItemTranslator returns an item translator between the items of 1.20.50 and the latest items.
+func newTestItemTranslator(t *testing.T) *DefaultItemTranslator {
+	t.Helper()
+	runtimeIDs, err := os.ReadFile("../protocols/v630/item_runtime_ids.nbt")
+	if err != nil {
+		t.Fatalf("read legacy item runtime IDs: %v", err)
+	}
+	required, err := os.ReadFile("../protocols/v630/required_item_list.json")
+	if err != nil {
+		t.Fatalf("read legacy required items: %v", err)
+	}
+	legacy, err := mapping.NewItemMapping(runtimeIDs, required, legacyItemVersion, false)
+	if err != nil {
+		t.Fatalf("load legacy items: %v", err)
+	}
+	latestMapping, err := latest.NewItemMapping(false)
+	if err != nil {
+		t.Fatalf("load latest items: %v", err)
+	}
+	return NewItemTranslator(legacy, latestMapping, newTestBlockTranslator(t))
+}
+
+// latestItem returns the latest item stack of the item with the name passed.
+func latestItem(t *testing.T, tr *DefaultItemTranslator, name string) protocol.ItemStack {
+	t.Helper()
+	rid, ok := tr.latest.ItemNameToRuntimeID(name)
+	if !ok {
+		t.Fatalf("%v missing from latest items", name)
+	}
+	return protocol.ItemStack{ItemType: protocol.ItemType{NetworkID: rid}, Count: 1}
+}
+
+// shapeless returns a shapeless recipe with the network ID passed crafting the output item from the input item.
+func shapeless(t *testing.T, tr *DefaultItemTranslator, networkID uint32, input, output string) *protocol.ShapelessRecipe {
+	stack := latestItem(t, tr, input)
+	return &protocol.ShapelessRecipe{
+		RecipeID:        input + "_to_" + output,
+		Input:           []protocol.ItemDescriptorCount{{Descriptor: &protocol.DefaultItemDescriptor{NetworkID: int16(stack.NetworkID)}, Count: 1}},
+		Output:          []protocol.ItemStack{latestItem(t, tr, output)},
+		RecipeNetworkID: networkID,
+	}
+}
+
+func TestDowngradeCraftingData(t *testing.T) {
+	tr := newTestItemTranslator(t)
+	tests := []struct {
+		name    string
+		recipes []protocol.Recipe
+		// want holds the server network IDs of the recipes kept, in the order of the client network IDs 1...
+		want []uint32
+	}{
+		{
+			name:    "representable recipes kept",
+			recipes: []protocol.Recipe{shapeless(t, tr, 10, "minecraft:oak_planks", "minecraft:stick"), shapeless(t, tr, 20, "minecraft:stick", "minecraft:oak_planks")},
+			want:    []uint32{10, 20},
+		},
+		{
+			name:    "recipe with new output dropped",
+			recipes: []protocol.Recipe{shapeless(t, tr, 10, "minecraft:breeze_rod", "minecraft:wind_charge"), shapeless(t, tr, 20, "minecraft:oak_planks", "minecraft:stick")},
+			want:    []uint32{20},
+		},
+		{
+			name:    "recipe with new input dropped",
+			recipes: []protocol.Recipe{shapeless(t, tr, 10, "minecraft:oak_planks", "minecraft:stick"), shapeless(t, tr, 20, "minecraft:breeze_rod", "minecraft:stick")},
+			want:    []uint32{10},
+		},
+		{
+			name: "recipe crafting air dropped",
+			recipes: []protocol.Recipe{&protocol.ShapelessRecipe{
+				Input:           []protocol.ItemDescriptorCount{{Descriptor: &protocol.DeferredItemDescriptor{Name: "minecraft:stick"}, Count: 1}},
+				Output:          []protocol.ItemStack{{}},
+				RecipeNetworkID: 10,
+			}},
+		},
+		{
+			name:    "multi recipe kept",
+			recipes: []protocol.Recipe{&protocol.MultiRecipe{RecipeNetworkID: 30}},
+			want:    []uint32{30},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := new(minecraft.Conn)
+			defer tr.Reset(conn)
+
+			pk := &packet.CraftingData{Recipes: test.recipes, ClearRecipes: true}
+			tr.DowngradeItemPackets([]packet.Packet{pk}, conn)
+			if len(pk.Recipes) != len(test.want) {
+				t.Fatalf("kept %v recipes, expected %v", len(pk.Recipes), len(test.want))
+			}
+			s := tr.sessions.Get(conn)
+			for i, recipe := range pk.Recipes {
+				clientID := *recipeNetworkID(recipe)
+				if clientID != uint32(i+1) {
+					t.Errorf("recipe %v has network ID %v, expected %v", i, clientID, i+1)
+				}
+				if serverID := s.recipeIDs[clientID]; serverID != test.want[i] {
+					t.Errorf("network ID %v maps to %v, expected %v", clientID, serverID, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestUpgradeCraftRecipeNetworkID(t *testing.T) {
+	tr := newTestItemTranslator(t)
+	tests := []struct {
+		name string
+		// clear specifies if the second CraftingData packet clears the recipes of the first.
+		clear bool
+		// clientID is the network ID the client crafts, and want the network ID the server should receive.
+		clientID, want uint32
+	}{
+		{name: "recipe of first packet", clientID: 1, want: 10},
+		{name: "recipe of second packet", clientID: 2, want: 20},
+		{name: "recipe after clear", clear: true, clientID: 1, want: 20},
+		{name: "unknown recipe", clientID: 5, want: 5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := new(minecraft.Conn)
+			defer tr.Reset(conn)
+
+			tr.DowngradeItemPackets([]packet.Packet{
+				&packet.CraftingData{Recipes: []protocol.Recipe{shapeless(t, tr, 10, "minecraft:oak_planks", "minecraft:stick")}, ClearRecipes: true},
+				&packet.CraftingData{Recipes: []protocol.Recipe{shapeless(t, tr, 20, "minecraft:stick", "minecraft:oak_planks")}, ClearRecipes: test.clear},
+			}, conn)
+
+			action := &protocol.CraftRecipeStackRequestAction{RecipeNetworkID: test.clientID}
+			tr.UpgradeItemPackets([]packet.Packet{&packet.ItemStackRequest{Requests: []protocol.ItemStackRequest{{Actions: []protocol.StackRequestAction{action}}}}}, conn)
+			if action.RecipeNetworkID != test.want {
+				t.Fatalf("crafted recipe %v, expected %v", action.RecipeNetworkID, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package translator
+
+import (
+	"github.com/oomph-ac/new-mv/mapping"
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+)
+
+// ItemRegistry is the item table built from the items in a StartGame packet for the version on the other end
+// of the connection.
+type ItemRegistry struct {
+	// Entries holds the item entries that were sent: the vanilla items of the version, the items defined by the
+	// server itself and the custom items registered as substitutes.
+	Entries []protocol.ItemEntry
+	// Dropped holds the entries of the StartGame packet that have no equivalent in the version and were left out.
+	Dropped []protocol.ItemEntry
+}
+
+// ItemRegistry returns the item table that was last built from a StartGame packet sent over the connection
+// passed.
+func (t *DefaultItemTranslator) ItemRegistry(conn *minecraft.Conn) ItemRegistry {
+	s := t.sessions.Get(conn)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.registry
+}
+
+// downgradeItemRegistry builds the item table of the legacy version from the entries sent by the server. It also
+// returns the latest and legacy item mappings holding the items defined by the server.
+func (t *DefaultItemTranslator) downgradeItemRegistry(entries []protocol.ItemEntry) (ItemRegistry, mapping.Item, mapping.Item) {
+	registry, latest, legacy := t.buildItemRegistry(entries, t.latest, t.mapping, t.DowngradeItemType)
+	for rid, i := range t.CustomItems() {
+		name, _ := i.EncodeItem()
+		registry.Entries = append(registry.Entries, protocol.ItemEntry{
+			Name:           name,
+			RuntimeID:      int16(rid),
+			ComponentBased: true,
+		})
+	}
+	return registry, latest, legacy
+}
+
+// upgradeItemRegistry builds the item table of the latest version from the entries sent by a legacy server. It
+// also returns the latest and legacy item mappings holding the items defined by the server.
+func (t *DefaultItemTranslator) upgradeItemRegistry(entries []protocol.ItemEntry) (ItemRegistry, mapping.Item, mapping.Item) {
+	registry, legacy, latest := t.buildItemRegistry(entries, t.mapping, t.latest, t.UpgradeItemType)
+	return registry, latest, legacy
+}
+
+// buildItemRegistry builds the item table of the mapping to from the entries passed, which are in the mapping
+// from. Vanilla entries are taken from the table the mapping was loaded with, while entries defined by the
+// server are given the next free runtime IDs of the mapping to. Vanilla entries of the server that translate
+// to a placeholder are reported as dropped. The entries defined by the server are returned as overlays of both
+// mappings, leaving the mappings themselves untouched.
+func (t *DefaultItemTranslator) buildItemRegistry(entries []protocol.ItemEntry, from, to mapping.Item, translate func(protocol.ItemType) protocol.ItemType) (ItemRegistry, mapping.Item, mapping.Item) {
+	vanilla := make(map[string]struct{})
+	for _, entry := range from.Entries() {
+		vanilla[entry.Name] = struct{}{}
+	}
+	placeholder, _ := to.ItemNameToRuntimeID("minecraft:info_update")
+
+	registry := ItemRegistry{Entries: to.Entries()}
+	names := make(map[string]struct{}, len(registry.Entries))
+	nextRID := int32(0)
+	for _, entry := range registry.Entries {
+		names[entry.Name] = struct{}{}
+		nextRID = max(nextRID, int32(entry.RuntimeID)+1)
+	}
+	for rid := range t.CustomItems() {
+		nextRID = max(nextRID, rid+1)
+	}
+
+	var fromEntries, toEntries []protocol.ItemEntry
+	for _, entry := range entries {
+		if _, ok := vanilla[entry.Name]; ok {
+			rid, _ := from.ItemNameToRuntimeID(entry.Name)
+			if itemType := translate(protocol.ItemType{NetworkID: rid}); itemType.NetworkID == placeholder && entry.Name != "minecraft:info_update" {
+				registry.Dropped = append(registry.Dropped, entry)
+			}
+			continue
+		}
+		if _, ok := names[entry.Name]; ok {
+			continue
+		}
+		fromEntries = append(fromEntries, entry)
+		entry.RuntimeID = int16(nextRID)
+		nextRID++
+		toEntries = append(toEntries, entry)
+
+		names[entry.Name] = struct{}{}
+		registry.Entries = append(registry.Entries, entry)
+	}
+	return registry, from.Overlay(fromEntries), to.Overlay(toEntries)
+}
@@ -0,0 +1,74 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// latestEntry returns the latest item entry of the vanilla item with the name passed.
+func latestEntry(t *testing.T, tr *DefaultItemTranslator, name string) protocol.ItemEntry {
+	t.Helper()
+	return protocol.ItemEntry{Name: name, RuntimeID: int16(latestItem(t, tr, name).NetworkID)}
+}
+
+func TestDowngradeItemRegistry(t *testing.T) {
+	tr := newTestItemTranslator(t)
+	legacyEntries := len(tr.mapping.Entries())
+	tests := []struct {
+		name    string
+		entries []protocol.ItemEntry
+		// custom holds the names of the items defined by the server that should be added to the table.
+		custom  []string
+		dropped []string
+	}{
+		{name: "vanilla items", entries: []protocol.ItemEntry{latestEntry(t, tr, "minecraft:stick"), latestEntry(t, tr, "minecraft:diamond_sword")}},
+		{name: "new vanilla item dropped", entries: []protocol.ItemEntry{latestEntry(t, tr, "minecraft:stick"), latestEntry(t, tr, "minecraft:mace")}, dropped: []string{"minecraft:mace"}},
+		{name: "server items added", entries: []protocol.ItemEntry{{Name: "test:gem", RuntimeID: 5000, ComponentBased: true}, {Name: "test:ore", RuntimeID: 5001}}, custom: []string{"test:gem", "test:ore"}},
+		{name: "duplicate server item added once", entries: []protocol.ItemEntry{{Name: "test:gem", RuntimeID: 5000}, {Name: "test:gem", RuntimeID: 5001}}, custom: []string{"test:gem"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := new(minecraft.Conn)
+			defer tr.Reset(conn)
+
+			pk := &packet.StartGame{Items: append([]protocol.ItemEntry(nil), test.entries...)}
+			tr.DowngradeItemPackets([]packet.Packet{pk}, conn)
+			registry := tr.ItemRegistry(conn)
+			if len(pk.Items) != legacyEntries+len(test.custom) || len(registry.Entries) != len(pk.Items) {
+				t.Fatalf("sent %v items, expected %v", len(pk.Items), legacyEntries+len(test.custom))
+			}
+			if len(registry.Dropped) != len(test.dropped) {
+				t.Fatalf("dropped %v items, expected %v", len(registry.Dropped), len(test.dropped))
+			}
+			for i, entry := range registry.Dropped {
+				if entry.Name != test.dropped[i] {
+					t.Errorf("dropped %v, expected %v", entry.Name, test.dropped[i])
+				}
+			}
+
+			adjusted := tr.forConn(conn)
+			for i, name := range test.custom {
+				sent := pk.Items[legacyEntries+i]
+				if sent.Name != name {
+					t.Fatalf("sent %v, expected %v", sent.Name, name)
+				}
+				if _, ok := tr.mapping.ItemRuntimeIDToName(int32(sent.RuntimeID)); ok {
+					t.Errorf("%v sent with runtime ID %v of a legacy vanilla item", name, sent.RuntimeID)
+				}
+				latestRID, ok := adjusted.latest.ItemNameToRuntimeID(name)
+				if !ok {
+					t.Fatalf("%v missing from latest items of connection", name)
+				}
+				if rid := adjusted.DowngradeItemType(protocol.ItemType{NetworkID: latestRID}).NetworkID; rid != int32(sent.RuntimeID) {
+					t.Errorf("%v downgraded to runtime ID %v, expected %v", name, rid, sent.RuntimeID)
+				}
+			}
+			if _, ok := tr.latest.ItemNameToRuntimeID("test:gem"); ok {
+				t.Fatalf("server item added to the shared latest items")
+			}
+		})
+	}
+}
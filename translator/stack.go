@@ -0,0 +1,174 @@
+package translator
+
+import (
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+// trackedStack is the item stack held by a single stack network ID, both as the server sent it and as it was
+// sent to the client after downgrading.
+type trackedStack struct {
+	latest, legacy protocol.ItemStack
+}
+
+// stackSlot is a slot in a container, as referred to by item stack requests and responses.
+type stackSlot struct {
+	container, slot byte
+}
+
+// slotOf returns the stackSlot of the slot info passed.
+func slotOf(info protocol.StackRequestSlotInfo) stackSlot {
+	return stackSlot{container: info.Container.ContainerID, slot: info.Slot}
+}
+
+// downgradeTrackedInstance downgrades the item instance passed, which is placed in the slot of the window passed,
+// and records the item its stack network ID holds in the session.
+func (t *DefaultItemTranslator) downgradeTrackedInstance(windowID, slot uint32, input protocol.ItemInstance, s *itemSession) protocol.ItemInstance {
+	latest := input.Stack
+	output := t.DowngradeItemInstance(input)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slots, ok := s.windowStacks[windowID]
+	if !ok {
+		slots = make(map[uint32]int32)
+		s.windowStacks[windowID] = slots
+	}
+	if previous, ok := slots[slot]; ok && previous != output.StackNetworkID {
+		delete(s.stacks, previous)
+	}
+	if output.StackNetworkID == 0 || output.Stack.NetworkID == t.mapping.Air() {
+		delete(slots, slot)
+		return output
+	}
+	slots[slot] = output.StackNetworkID
+	s.stacks[output.StackNetworkID] = trackedStack{latest: latest, legacy: output.Stack}
+	return output
+}
+
+// upgradeTrackedInstance upgrades the item instance passed. If its stack network ID is known to hold an item that
+// downgraded to the same legacy item, the item the server sent is used rather than the lossy upgrade of the
+// legacy item.
+func (t *DefaultItemTranslator) upgradeTrackedInstance(input protocol.ItemInstance, s *itemSession) protocol.ItemInstance {
+	legacy := input.Stack
+	output := t.UpgradeItemInstance(input)
+	if input.StackNetworkID == 0 {
+		return output
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tracked, ok := s.stacks[input.StackNetworkID]; ok && tracked.legacy.ItemType == legacy.ItemType {
+		output.Stack.ItemType = tracked.latest.ItemType
+		output.Stack.BlockRuntimeID = tracked.latest.BlockRuntimeID
+	}
+	return output
+}
+
+// upgradeTrackedStack upgrades the item stack passed, which has no stack network ID. If the stacks the client
+// currently holds that have the same legacy item all downgraded from the same latest item, that latest item is
+// used rather than the lossy upgrade of the legacy item. If they downgraded from different latest items, such
+// as several items that have no legacy equivalent, the legacy item is upgraded as it is.
+func (t *DefaultItemTranslator) upgradeTrackedStack(input protocol.ItemStack, s *itemSession) protocol.ItemStack {
+	legacy := input.ItemType
+	output := t.UpgradeItemStack(input)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var (
+		latest protocol.ItemType
+		found  bool
+	)
+	for _, tracked := range s.stacks {
+		if tracked.legacy.ItemType != legacy {
+			continue
+		}
+		if found && tracked.latest.ItemType != latest {
+			return output
+		}
+		latest, found = tracked.latest.ItemType, true
+	}
+	if found {
+		output.ItemType = latest
+	}
+	return output
+}
+
+// trackStackRequest records the stack network IDs that the item stack request passed moves into other slots,
+// so that the stack network IDs the server assigns to those slots in its response can be resolved.
+func (s *itemSession) trackStackRequest(request protocol.ItemStackRequest) {
+	moves := make(map[stackSlot]int32)
+	for _, action := range request.Actions {
+		switch action := action.(type) {
+		case *protocol.TakeStackRequestAction:
+			moves[slotOf(action.Destination)] = action.Source.StackNetworkID
+		case *protocol.PlaceStackRequestAction:
+			moves[slotOf(action.Destination)] = action.Source.StackNetworkID
+		case *protocol.SwapStackRequestAction:
+			moves[slotOf(action.Destination)] = action.Source.StackNetworkID
+			moves[slotOf(action.Source)] = action.Destination.StackNetworkID
+		}
+	}
+	if len(moves) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pendingMoves[request.RequestID] = moves
+}
+
+// clearWindow forgets all stack network IDs held in the window passed.
+func (s *itemSession) clearWindow(windowID uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, stackNetworkID := range s.windowStacks[windowID] {
+		delete(s.stacks, stackNetworkID)
+	}
+	delete(s.windowStacks, windowID)
+}
+
+// trackStackResponse updates the counts of the stacks held by the stack network IDs in the ItemStackResponse
+// passed, and records the items held by the stack network IDs it assigns to the slots that the request it
+// responds to moved a known stack into.
+func (s *itemSession) trackStackResponse(pk *packet.ItemStackResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, response := range pk.Responses {
+		moves := s.pendingMoves[response.RequestID]
+		delete(s.pendingMoves, response.RequestID)
+		if response.Status != protocol.ItemStackResponseStatusOK {
+			continue
+		}
+		for _, info := range response.ContainerInfo {
+			for _, slot := range info.SlotInfo {
+				tracked, ok := s.stacks[slot.StackNetworkID]
+				if !ok {
+					source, moved := moves[stackSlot{container: info.Container.ContainerID, slot: slot.Slot}]
+					if !moved {
+						continue
+					}
+					if tracked, ok = s.stacks[source]; !ok {
+						continue
+					}
+				}
+				if slot.Count == 0 {
+					delete(s.stacks, slot.StackNetworkID)
+					continue
+				}
+				tracked.latest.Count, tracked.legacy.Count = uint16(slot.Count), uint16(slot.Count)
+				s.stacks[slot.StackNetworkID] = tracked
+			}
+		}
+	}
+}
+
+// StackNetworkItem returns the item stack, as sent by the server, that the stack network ID passed was last
+// known to hold for the connection passed.
+func (t *DefaultItemTranslator) StackNetworkItem(conn *minecraft.Conn, stackNetworkID int32) (protocol.ItemStack, bool) {
+	s := t.sessions.Get(conn)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tracked, ok := s.stacks[stackNetworkID]
+	return tracked.latest, ok
+}
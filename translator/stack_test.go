@@ -0,0 +1,152 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft"
+	"github.com/sandertv/gophertunnel/minecraft/protocol"
+	"github.com/sandertv/gophertunnel/minecraft/protocol/packet"
+)
+
+const (
+	// inventoryContainer and cursorContainer are the container IDs of the inventory and the cursor in item stack
+	// requests and responses.
+	inventoryContainer, cursorContainer = 0x1b, 0x3a
+	// sentStackID is the stack network ID of the item sent to the client, and movedStackID the stack network ID
+	// the server assigns to it after it was moved to the cursor.
+	sentStackID, movedStackID = 5, 9
+)
+
+// takeRequest returns an item stack request with the ID passed that takes the item with the stack network ID
+// sentStackID from the first inventory slot to the cursor.
+func takeRequest(requestID int32) *packet.ItemStackRequest {
+	take := &protocol.TakeStackRequestAction{}
+	take.Source = protocol.StackRequestSlotInfo{Container: protocol.FullContainerName{ContainerID: inventoryContainer}, StackNetworkID: sentStackID}
+	take.Destination = protocol.StackRequestSlotInfo{Container: protocol.FullContainerName{ContainerID: cursorContainer}}
+	return &packet.ItemStackRequest{Requests: []protocol.ItemStackRequest{{RequestID: requestID, Actions: []protocol.StackRequestAction{take}}}}
+}
+
+// takeResponse returns the response to the request with the ID passed, which assigns a stack of the count
+// passed with the stack network ID movedStackID to the cursor.
+func takeResponse(requestID int32, status uint8, count byte) *packet.ItemStackResponse {
+	return &packet.ItemStackResponse{Responses: []protocol.ItemStackResponse{{Status: status, RequestID: requestID, ContainerInfo: []protocol.StackResponseContainerInfo{
+		{Container: protocol.FullContainerName{ContainerID: cursorContainer}, SlotInfo: []protocol.StackResponseSlotInfo{{Count: count, StackNetworkID: movedStackID}}},
+	}}}}
+}
+
+func TestStackNetworkIDTracking(t *testing.T) {
+	tr := newTestItemTranslator(t)
+	tests := []struct {
+		name string
+		// request and response are sent after the mace was placed in the first inventory slot, if not nil.
+		request  *packet.ItemStackRequest
+		response *packet.ItemStackResponse
+		// clear specifies if the inventory is cleared after the response.
+		clear bool
+		// want holds for every stack network ID if it should still resolve to the mace, and with what count.
+		want map[int32]uint16
+	}{
+		{name: "sent item tracked", want: map[int32]uint16{sentStackID: 2}},
+		{name: "moved item tracked", request: takeRequest(1), response: takeResponse(1, protocol.ItemStackResponseStatusOK, 1), want: map[int32]uint16{sentStackID: 2, movedStackID: 1}},
+		{name: "rejected move ignored", request: takeRequest(1), response: takeResponse(1, protocol.ItemStackResponseStatusError, 1), want: map[int32]uint16{sentStackID: 2}},
+		{name: "response to other request ignored", request: takeRequest(1), response: takeResponse(2, protocol.ItemStackResponseStatusOK, 1), want: map[int32]uint16{sentStackID: 2}},
+		{name: "emptied stack forgotten", response: &packet.ItemStackResponse{Responses: []protocol.ItemStackResponse{{ContainerInfo: []protocol.StackResponseContainerInfo{
+			{Container: protocol.FullContainerName{ContainerID: inventoryContainer}, SlotInfo: []protocol.StackResponseSlotInfo{{StackNetworkID: sentStackID}}},
+		}}}}, want: map[int32]uint16{}},
+		{name: "cleared window forgotten", clear: true, want: map[int32]uint16{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := new(minecraft.Conn)
+			defer tr.Reset(conn)
+
+			mace := latestItem(t, tr, "minecraft:mace")
+			mace.Count = 2
+			tr.DowngradeItemPackets([]packet.Packet{&packet.InventoryContent{WindowID: protocol.WindowIDInventory, Content: []protocol.ItemInstance{{StackNetworkID: sentStackID, Stack: mace}}}}, conn)
+			if test.request != nil {
+				tr.UpgradeItemPackets([]packet.Packet{test.request}, conn)
+			}
+			if test.response != nil {
+				tr.DowngradeItemPackets([]packet.Packet{test.response}, conn)
+			}
+			if test.clear {
+				tr.DowngradeItemPackets([]packet.Packet{&packet.InventoryContent{WindowID: protocol.WindowIDInventory}}, conn)
+			}
+
+			for _, stackNetworkID := range []int32{sentStackID, movedStackID} {
+				stack, ok := tr.StackNetworkItem(conn, stackNetworkID)
+				count, want := test.want[stackNetworkID]
+				if ok != want {
+					t.Fatalf("stack network ID %v tracked: %v, expected %v", stackNetworkID, ok, want)
+				}
+				if ok && (stack.NetworkID != mace.NetworkID || stack.Count != count) {
+					t.Fatalf("stack network ID %v holds %v of %v, expected %v of %v", stackNetworkID, stack.Count, stack.NetworkID, count, mace.NetworkID)
+				}
+			}
+		})
+	}
+}
+
+func TestUpgradeTrackedInstance(t *testing.T) {
+	tr := newTestItemTranslator(t)
+	conn := new(minecraft.Conn)
+	defer tr.Reset(conn)
+
+	mace := latestItem(t, tr, "minecraft:mace")
+	content := &packet.InventoryContent{WindowID: protocol.WindowIDInventory, Content: []protocol.ItemInstance{{StackNetworkID: sentStackID, Stack: mace}}}
+	tr.DowngradeItemPackets([]packet.Packet{content}, conn)
+	legacy := content.Content[0].Stack
+
+	tests := []struct {
+		name     string
+		instance protocol.ItemInstance
+		want     int32
+	}{
+		{name: "tracked stack network ID", instance: protocol.ItemInstance{StackNetworkID: sentStackID, Stack: legacy}, want: mace.NetworkID},
+		{name: "unknown stack network ID", instance: protocol.ItemInstance{StackNetworkID: movedStackID, Stack: legacy}, want: tr.UpgradeItemStack(legacy).NetworkID},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pk := &packet.MobEquipment{NewItem: test.instance}
+			tr.UpgradeItemPackets([]packet.Packet{pk}, conn)
+			if pk.NewItem.Stack.NetworkID != test.want {
+				t.Fatalf("upgraded to item %v, expected %v", pk.NewItem.Stack.NetworkID, test.want)
+			}
+		})
+	}
+}
+
+func TestUpgradeTrackedStack(t *testing.T) {
+	tr := newTestItemTranslator(t)
+	mace, windCharge := latestItem(t, tr, "minecraft:mace"), latestItem(t, tr, "minecraft:wind_charge")
+	legacy := tr.DowngradeItemStack(mace)
+
+	tests := []struct {
+		name string
+		// held holds the items the client holds, with the stack network IDs 1...
+		held []protocol.ItemStack
+		want int32
+	}{
+		{name: "single latest item", held: []protocol.ItemStack{mace, mace}, want: mace.NetworkID},
+		{name: "several latest items", held: []protocol.ItemStack{mace, windCharge}, want: tr.UpgradeItemStack(legacy).NetworkID},
+		{name: "no item held", want: tr.UpgradeItemStack(legacy).NetworkID},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := new(minecraft.Conn)
+			defer tr.Reset(conn)
+
+			content := &packet.InventoryContent{WindowID: protocol.WindowIDInventory}
+			for i, stack := range test.held {
+				content.Content = append(content.Content, protocol.ItemInstance{StackNetworkID: int32(i + 1), Stack: stack})
+			}
+			tr.DowngradeItemPackets([]packet.Packet{content}, conn)
+
+			action := &protocol.CraftResultsDeprecatedStackRequestAction{ResultItems: []protocol.ItemStack{legacy}}
+			tr.UpgradeItemPackets([]packet.Packet{&packet.ItemStackRequest{Requests: []protocol.ItemStackRequest{{Actions: []protocol.StackRequestAction{action}}}}}, conn)
+			if action.ResultItems[0].NetworkID != test.want {
+				t.Fatalf("upgraded to item %v, expected %v", action.ResultItems[0].NetworkID, test.want)
+			}
+		})
+	}
+}